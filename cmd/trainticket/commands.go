@@ -0,0 +1,792 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ticketArg parses the leading TICKET argument of args and returns the rest.
+func ticketArg(args []string) (int32, []string, error) {
+	if len(args) == 0 {
+		return 0, nil, errors.New("ticket number is required")
+	}
+	n, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid ticket number %q", args[0])
+	}
+	return int32(n), args[1:], nil
+}
+
+// travelClass parses a travel class name such as "first". An empty name is
+// TRAVEL_CLASS_UNSPECIFIED.
+func travelClass(name string) (model.TravelClass, error) {
+	if name == "" {
+		return model.TravelClass_TRAVEL_CLASS_UNSPECIFIED, nil
+	}
+	class, ok := model.TravelClass_value["TRAVEL_CLASS_"+strings.ToUpper(name)]
+	if !ok || class == int32(model.TravelClass_TRAVEL_CLASS_UNSPECIFIED) {
+		return 0, fmt.Errorf("unknown travel class %q", name)
+	}
+	return model.TravelClass(class), nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func runPurchase(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("purchase", flag.ExitOnError)
+	from := fs.String("from", "", "departure city")
+	to := fs.String("to", "", "arrival city")
+	first := fs.String("first", "", "passenger first name")
+	last := fs.String("last", "", "passenger last name")
+	email := fs.String("email", "", "passenger email")
+	userID := fs.String("user-id", "", "registered user ID, instead of passenger details")
+	userToken := c.userFlag(fs)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	assist := fs.String("assist", "", "comma-separated special assistance needs")
+	className := fs.String("class", "", "travel class: standard or first (any if empty)")
+	fs.Parse(args)
+	class, err := travelClass(*className)
+	if err != nil {
+		return err
+	}
+
+	req := &model.PurchaseRequest{
+		From:            *from,
+		To:              *to,
+		UserId:          *userID,
+		TripId:          *trip,
+		AssistanceNeeds: splitList(*assist),
+		TravelClass:     class,
+	}
+	if *userID == "" {
+		req.User = &model.User{FirstName: *first, LastName: *last, Email: *email}
+	}
+	// Only the user themselves may book on their profile
+	ctx = c.asUser(ctx, *userToken)
+	res, err := c.client.PurchaseTicket(ctx, req)
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	seat := res.SeatNumber
+	if res.Overbooked {
+		seat = "assigned at check-in"
+	}
+	if err := c.out.table([]string{"TICKET", "TRIP", "SECTION", "SEAT"}, [][]string{
+		{fmt.Sprint(res.TicketNumber), res.TripId, res.Section, seat},
+	}); err != nil {
+		return err
+	}
+	if res.UserToken != "" {
+		_, err = fmt.Fprintf(c.out.w, "\nYour user token, needed to check in or manage this ticket:\n%s\n", res.UserToken)
+	}
+	return err
+}
+
+func runReceipt(ctx context.Context, c *cli, args []string) error {
+	ticket, _, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	res, err := c.client.GetReceipt(ctx, &model.GetReceiptRequest{TicketNumber: ticket})
+	if err != nil {
+		return err
+	}
+	return c.out.tickets(res, []*model.Ticket{res.Ticket})
+}
+
+var sortOrders = map[string]model.TicketSortOrder{
+	"seat":    model.TicketSortOrder_TICKET_SORT_ORDER_SEAT,
+	"surname": model.TicketSortOrder_TICKET_SORT_ORDER_SURNAME,
+	"ticket":  model.TicketSortOrder_TICKET_SORT_ORDER_TICKET_NUMBER,
+}
+
+func runListSection(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("list-section", flag.ExitOnError)
+	section := fs.String("section", "", "section (all sections if empty)")
+	trip := fs.String("trip", "", "trip ID")
+	sortBy := fs.String("sort", "seat", "order: seat, surname or ticket")
+	email := fs.String("email", "", "passenger email")
+	name := fs.String("name", "", "passenger name prefix")
+	pageSize := fs.Int("page-size", 0, "tickets per page (server default if 0)")
+	pageToken := fs.String("page-token", "", "token from a previous page")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+
+	order, ok := sortOrders[*sortBy]
+	if !ok {
+		return fmt.Errorf("unknown sort order %q", *sortBy)
+	}
+	res, err := c.client.ViewUsersBySection(c.asStaff(ctx, *staffKey), &model.ViewUsersBySectionRequest{
+		Section:    *section,
+		TripId:     *trip,
+		SortBy:     order,
+		Email:      *email,
+		NamePrefix: *name,
+		PageSize:   int32(*pageSize),
+		PageToken:  *pageToken,
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.out.tickets(res, res.Tickets); err != nil {
+		return err
+	}
+	if c.out.format != "json" && res.NextPageToken != "" {
+		fmt.Fprintf(os.Stderr, "next page: -page-token %s\n", res.NextPageToken)
+	}
+	return nil
+}
+
+func runRemove(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	version := fs.Int64("version", 0, "only remove the ticket at this version")
+	fs.Parse(rest)
+
+	res, err := c.client.RemoveUser(ctx, &model.RemoveUserRequest{TicketNumber: ticket, ExpectedVersion: *version})
+	if err != nil {
+		return err
+	}
+	return c.out.message(res)
+}
+
+func runModifySeat(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("modify-seat", flag.ExitOnError)
+	section := fs.String("section", "", "new section")
+	seat := fs.String("seat", "", "new seat (first free seat in the section if empty)")
+	version := fs.Int64("version", 0, "only move the ticket at this version")
+	userToken := c.userFlag(fs)
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+
+	res, err := c.client.ModifyUserSeat(c.asUser(c.asStaff(ctx, *staffKey), *userToken), &model.ModifySeatRequest{
+		TicketNumber:    ticket,
+		NewSection:      *section,
+		NewSeatNumber:   *seat,
+		ExpectedVersion: *version,
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.message(res)
+}
+
+func runChangeClass(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("change-class", flag.ExitOnError)
+	className := fs.String("class", "", "new travel class: standard or first")
+	version := fs.Int64("version", 0, "only change the ticket at this version")
+	userToken := c.userFlag(fs)
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+	class, err := travelClass(*className)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.client.ChangeClass(c.asUser(c.asStaff(ctx, *staffKey), *userToken), &model.ChangeClassRequest{
+		TicketNumber:    ticket,
+		TravelClass:     class,
+		ExpectedVersion: *version,
+	})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	return c.out.table([]string{"TICKET", "SECTION", "SEAT", "PRICE", "FARE DIFFERENCE", "PAYMENT"}, [][]string{{
+		fmt.Sprint(res.Ticket.TicketNumber),
+		res.Ticket.Section,
+		res.Ticket.SeatNumber,
+		fmt.Sprintf("%.2f", res.Ticket.PricePaid),
+		fmt.Sprintf("%+.2f", res.FareDifference),
+		res.PaymentId,
+	}})
+}
+
+func runExchange(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("exchange", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip to exchange the ticket for")
+	className := fs.String("class", "", "travel class on the new trip (the ticket's class if empty)")
+	version := fs.Int64("version", 0, "only exchange the ticket at this version")
+	userToken := c.userFlag(fs)
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+	class, err := travelClass(*className)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.client.ExchangeTicket(c.asUser(c.asStaff(ctx, *staffKey), *userToken), &model.ExchangeTicketRequest{
+		TicketNumber:    ticket,
+		TripId:          *trip,
+		TravelClass:     class,
+		ExpectedVersion: *version,
+	})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	return c.out.table([]string{"TICKET", "TRIP", "SECTION", "SEAT", "CHANGE FEE", "FARE DIFFERENCE", "AMOUNT DUE", "PAYMENT"}, [][]string{{
+		fmt.Sprint(res.Ticket.TicketNumber),
+		res.Ticket.TripId,
+		res.Ticket.Section,
+		res.Ticket.SeatNumber,
+		fmt.Sprintf("%.2f", res.ChangeFee),
+		fmt.Sprintf("%+.2f", res.FareDifference),
+		fmt.Sprintf("%+.2f", res.AmountDue),
+		res.PaymentId,
+	}})
+}
+
+func runSwapConsent(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("swap-consent", flag.ExitOnError)
+	with := fs.Int("with", 0, "ticket to trade seats with")
+	userToken := c.userFlag(fs)
+	fs.Parse(rest)
+
+	ctx = c.asUser(ctx, *userToken)
+	res, err := c.client.ConsentToSwap(ctx, &model.ConsentToSwapRequest{TicketNumber: ticket, WithTicketNumber: int32(*with)})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	_, err = fmt.Fprintln(c.out.w, res.ConsentToken)
+	return err
+}
+
+func runSwap(ctx context.Context, c *cli, args []string) error {
+	first, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	second, rest, err := ticketArg(rest)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("swap", flag.ExitOnError)
+	firstConsent := fs.String("consent1", "", "consent token of the first ticket's owner")
+	secondConsent := fs.String("consent2", "", "consent token of the second ticket's owner")
+	override := fs.Bool("override", false, "swap without consent, as staff")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+
+	req := &model.SwapSeatsRequest{
+		FirstTicketNumber:  first,
+		SecondTicketNumber: second,
+		FirstConsentToken:  *firstConsent,
+		SecondConsentToken: *secondConsent,
+	}
+	if *override || *staffKey != "" {
+		ctx = c.asStaff(ctx, *staffKey)
+		req.StaffOverride = true
+	}
+	res, err := c.client.SwapSeats(ctx, req)
+	if err != nil {
+		return err
+	}
+	return c.out.tickets(res, []*model.Ticket{res.First, res.Second})
+}
+
+// timeFlag parses an optional RFC 3339 time flag.
+func timeFlag(name, value string) (*timestamppb.Timestamp, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -%s: %v", name, err)
+	}
+	return timestamppb.New(t), nil
+}
+
+func runBlock(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("block", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	seats := fs.String("seats", "", "comma-separated seats to block")
+	section := fs.String("section", "", "section to block, instead of seats")
+	reason := fs.String("reason", "", "why the seats are out of service")
+	from := fs.String("from", "", "start of the block, RFC 3339 (now if empty)")
+	until := fs.String("until", "", "end of the block, RFC 3339 (until lifted if empty)")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+	start, err := timeFlag("from", *from)
+	if err != nil {
+		return err
+	}
+	end, err := timeFlag("until", *until)
+	if err != nil {
+		return err
+	}
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.BlockSeats(ctx, &model.BlockSeatsRequest{
+		TripId:      *trip,
+		SeatNumbers: splitList(*seats),
+		Section:     *section,
+		Reason:      *reason,
+		StartTime:   start,
+		EndTime:     end,
+	})
+	if err != nil {
+		return err
+	}
+	return c.out.seatBlocks(res, []*model.SeatBlock{res.Block}, res.Proposals)
+}
+
+func runUnblock(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return errors.New("block ID is required")
+	}
+	fs := flag.NewFlagSet("unblock", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args[1:])
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.UnblockSeats(ctx, &model.UnblockSeatsRequest{TripId: *trip, BlockId: args[0]})
+	if err != nil {
+		return err
+	}
+	return c.out.message(res)
+}
+
+func runBlocks(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("blocks", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.ListSeatBlocks(ctx, &model.ListSeatBlocksRequest{TripId: *trip})
+	if err != nil {
+		return err
+	}
+	return c.out.seatBlocks(res, res.Blocks, res.Proposals)
+}
+
+func runRelocate(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return errors.New("section is required")
+	}
+	fs := flag.NewFlagSet("relocate", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	reason := fs.String("reason", "", "why the section is cancelled")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args[1:])
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.RelocateSection(ctx, &model.RelocateSectionRequest{TripId: *trip, Section: args[0], Reason: *reason})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	rows := make([][]string, 0, len(res.Relocations)+len(res.Waitlisted))
+	for _, r := range append(res.Relocations, res.Waitlisted...) {
+		newSeat := r.NewSeatNumber
+		if newSeat == "" {
+			newSeat = "waitlisted"
+		}
+		rows = append(rows, []string{fmt.Sprint(r.TicketNumber), r.UserId, r.OldSeatNumber, newSeat})
+	}
+	return c.out.table([]string{"TICKET", "USER", "OLD SEAT", "NEW SEAT"}, rows)
+}
+
+func runCheckIn(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("check-in", flag.ExitOnError)
+	version := fs.Int64("version", 0, "only check in the ticket at this version")
+	userToken := c.userFlag(fs)
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+
+	// The ticket's owner or staff
+	ctx = c.asUser(c.asStaff(ctx, *staffKey), *userToken)
+	res, err := c.client.CheckIn(ctx, &model.CheckInRequest{TicketNumber: ticket, ExpectedVersion: *version})
+	if err != nil {
+		return err
+	}
+	return c.out.tickets(res, []*model.Ticket{res.Ticket})
+}
+
+func runBoard(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("board", flag.ExitOnError)
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.Board(ctx, &model.BoardRequest{TicketNumber: ticket})
+	if err != nil {
+		return err
+	}
+	return c.out.tickets(res, []*model.Ticket{res.Ticket})
+}
+
+func runNoShows(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("no-shows", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	release := fs.Bool("release", false, "free the no-shows' seats")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.ProcessNoShows(ctx, &model.ProcessNoShowsRequest{TripId: *trip, ReleaseSeats: *release})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	rows := make([][]string, 0, len(res.TicketNumbers))
+	for _, n := range res.TicketNumbers {
+		rows = append(rows, []string{fmt.Sprint(n)})
+	}
+	if err := c.out.table([]string{"NO-SHOW TICKET"}, rows); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.out.w, "%d seats released\n", res.SeatsReleased)
+	return err
+}
+
+func runBoardingPass(ctx context.Context, c *cli, args []string) error {
+	ticket, rest, err := ticketArg(args)
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("boarding-pass", flag.ExitOnError)
+	size := fs.Int("size", 0, "QR code width in pixels (server default if 0)")
+	out := fs.String("o", "", "QR code PNG file (boarding-pass-TICKET.png if empty)")
+	userToken := c.userFlag(fs)
+	staffKey := c.staffFlag(fs)
+	fs.Parse(rest)
+
+	// The ticket's owner or staff
+	ctx = c.asUser(c.asStaff(ctx, *staffKey), *userToken)
+
+	res, err := c.client.GetBoardingPass(ctx, &model.GetBoardingPassRequest{TicketNumber: ticket, QrSize: int32(*size)})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("boarding-pass-%d.png", ticket)
+	}
+	if err := os.WriteFile(*out, res.QrPng, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", *out)
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	return c.out.table([]string{"TICKET", "TRIP", "SEAT", "PASSENGER", "TOKEN"}, [][]string{{
+		fmt.Sprint(res.Pass.TicketNumber),
+		res.Pass.TripId,
+		res.Pass.SeatNumber,
+		res.Pass.PassengerName,
+		res.Token,
+	}})
+}
+
+func runVerifyPass(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return errors.New("token is required")
+	}
+	fs := flag.NewFlagSet("verify-pass", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args[1:])
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.VerifyBoardingPass(ctx, &model.VerifyBoardingPassRequest{Token: args[0], TripId: *trip})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	verdict := "VALID"
+	if !res.Valid {
+		verdict = "INVALID: " + res.Reason
+	}
+	return c.out.table([]string{"TICKET", "SEAT", "PASSENGER", "VERDICT"}, [][]string{{
+		fmt.Sprint(res.Pass.GetTicketNumber()),
+		res.Pass.GetSeatNumber(),
+		res.Pass.GetPassengerName(),
+		verdict,
+	}})
+}
+
+// publicKey parses a base64 Ed25519 public key, as GetBoardingPassKey
+// returns it over the gateway.
+func publicKey(s string) (ed25519.PublicKey, error) {
+	if s == "" {
+		return nil, errors.New("-key is required: the boarding pass public key to check the bundle against")
+	}
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key %q", s)
+	}
+	return key, nil
+}
+
+func runExportBundle(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("export-bundle", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	out := fs.String("o", "", "bundle file (validation-bundle-TRIP.pb if empty)")
+	key := fs.String("key", "", "base64 boarding pass public key, pinned from GetBoardingPassKey, to check the bundle against")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+	trusted, err := publicKey(*key)
+	if err != nil {
+		return err
+	}
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.ExportValidationBundle(ctx, &model.ExportValidationBundleRequest{TripId: *trip})
+	if err != nil {
+		return err
+	}
+	bundle, err := boardingpass.OpenBundle(res.Bundle, res.Signature, trusted)
+	if err != nil {
+		return err
+	}
+	// The file holds the response, bundle and signature together
+	data, err := proto.Marshal(res)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = fmt.Sprintf("validation-bundle-%s.pb", bundle.TripId)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", *out)
+	if c.out.format == "json" {
+		return c.out.json(bundle)
+	}
+	return c.out.table([]string{"TRIP", "TICKETS", "KEY", "BYTES"}, [][]string{{
+		bundle.TripId,
+		fmt.Sprint(len(bundle.Tickets)),
+		bundle.KeyId,
+		fmt.Sprint(len(data)),
+	}})
+}
+
+func runOverbooking(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("overbooking", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	section := fs.String("section", "", "section to set (every section if empty)")
+	percent := fs.Int("percent", -1, "extra tickets to sell, as a percentage of seats (show the policy if unset)")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+
+	ctx = c.asStaff(ctx, *staffKey)
+	var res interface {
+		proto.Message
+		GetPolicies() []*model.OverbookingPolicy
+	}
+	var err error
+	if *percent < 0 {
+		res, err = c.client.GetOverbooking(ctx, &model.GetOverbookingRequest{TripId: *trip})
+	} else {
+		res, err = c.client.SetOverbooking(ctx, &model.SetOverbookingRequest{TripId: *trip, Section: *section, Percent: int32(*percent)})
+	}
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	rows := make([][]string, 0, len(res.GetPolicies()))
+	for _, p := range res.GetPolicies() {
+		rows = append(rows, []string{p.Section, fmt.Sprintf("%d%%", p.Percent), fmt.Sprint(p.Capacity), fmt.Sprint(p.Limit), fmt.Sprint(p.Unassigned)})
+	}
+	return c.out.table([]string{"SECTION", "OVERBOOKING", "SEATS", "LIMIT", "UNASSIGNED"}, rows)
+}
+
+func runOffload(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return errors.New("section is required")
+	}
+	fs := flag.NewFlagSet("offload", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	rebookTrip := fs.String("rebook-trip", "", "trip to rebook offloaded passengers on (refund if empty)")
+	compensation := fs.Float64("compensation", 0, "amount paid to each offloaded passenger")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args[1:])
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.OffloadSection(ctx, &model.OffloadSectionRequest{
+		TripId:       *trip,
+		Section:      args[0],
+		RebookTripId: *rebookTrip,
+		Compensation: float32(*compensation),
+	})
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	rows := make([][]string, 0, len(res.Seated)+len(res.Offloaded))
+	for _, r := range res.Seated {
+		rows = append(rows, []string{fmt.Sprint(r.TicketNumber), r.UserId, "seated in " + r.NewSeatNumber, ""})
+	}
+	for _, o := range res.Offloaded {
+		outcome := fmt.Sprintf("refunded %.2f", o.Refund)
+		if o.RebookedTicketNumber != 0 {
+			outcome = fmt.Sprintf("rebooked as %d in %s", o.RebookedTicketNumber, o.RebookedSeatNumber)
+		}
+		rows = append(rows, []string{fmt.Sprint(o.TicketNumber), o.UserId, outcome, fmt.Sprintf("%.2f", o.Compensation)})
+	}
+	return c.out.table([]string{"TICKET", "USER", "OUTCOME", "COMPENSATION"}, rows)
+}
+
+func runCreateTrip(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 {
+		return errors.New("trip ID is required")
+	}
+	fs := flag.NewFlagSet("create-trip", flag.ExitOnError)
+	sections := fs.String("sections", "", "comma-separated sections as NAME:CLASS:SEATS, such as A:standard:10")
+	departs := fs.String("departs", "", "departure time, RFC 3339")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args[1:])
+
+	departsAt, err := timeFlag("departs", *departs)
+	if err != nil {
+		return err
+	}
+	req := &model.CreateTripRequest{TripId: args[0], DepartsAt: departsAt}
+	for _, spec := range splitList(*sections) {
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid section %q, want NAME:CLASS:SEATS", spec)
+		}
+		class, err := travelClass(parts[1])
+		if err != nil {
+			return err
+		}
+		seats, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return fmt.Errorf("invalid seat count %q", parts[2])
+		}
+		req.Sections = append(req.Sections, &model.TripSection{Section: parts[0], TravelClass: class, Seats: int32(seats)})
+	}
+
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.CreateTrip(ctx, req)
+	if err != nil {
+		return err
+	}
+	if c.out.format == "json" {
+		return c.out.json(res)
+	}
+	rows := make([][]string, 0, len(res.Sections))
+	for _, section := range res.Sections {
+		rows = append(rows, []string{res.TripId, section.Section, strings.ToLower(strings.TrimPrefix(section.TravelClass.String(), "TRAVEL_CLASS_")), fmt.Sprint(section.Seats)})
+	}
+	return c.out.table([]string{"TRIP", "SECTION", "CLASS", "SEATS"}, rows)
+}
+
+func runSeatMap(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("seatmap", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	fs.Parse(args)
+
+	res, err := c.client.GetSeatMap(ctx, &model.GetSeatMapRequest{TripId: *trip})
+	if err != nil {
+		return err
+	}
+	return c.out.seatMap(res)
+}
+
+var manifestFormats = map[string]model.ManifestFormat{
+	"csv":  model.ManifestFormat_MANIFEST_FORMAT_CSV,
+	"json": model.ManifestFormat_MANIFEST_FORMAT_JSON,
+	"html": model.ManifestFormat_MANIFEST_FORMAT_HTML,
+}
+
+func runManifest(ctx context.Context, c *cli, args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	trip := fs.String("trip", "", "trip ID (default trip if empty)")
+	format := fs.String("format", "csv", "output format: csv, json or html")
+	out := fs.String("o", "", "output file (the server's suggested name if empty, - for stdout)")
+	staffKey := c.staffFlag(fs)
+	fs.Parse(args)
+
+	f, ok := manifestFormats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	ctx = c.asStaff(ctx, *staffKey)
+	res, err := c.client.ExportManifest(ctx, &model.ExportManifestRequest{TripId: *trip, Format: f})
+	if err != nil {
+		return err
+	}
+
+	switch *out {
+	case "-":
+		_, err = c.out.w.Write(res.Content)
+		return err
+	case "":
+		*out = res.Filename
+	}
+	if err := os.WriteFile(*out, res.Content, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", *out)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// config holds the connection settings read from the config file. Command
+// line flags override it.
+type config struct {
+	Addr    string   `json:"addr"`
+	Timeout duration `json:"timeout"`
+	Output  string   `json:"output"`
+	// Sent by staff commands; keep the file readable only by its owner.
+	StaffKey string `json:"staff_key"`
+	// Proves who the passenger is to commands acting on their tickets.
+	UserToken string `json:"user_token"`
+}
+
+// duration is a time.Duration written as a string such as "10s" in JSON.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+func defaultConfig() config {
+	return config{
+		Addr:    "localhost:50051",
+		Timeout: duration(10 * time.Second),
+		Output:  "table",
+	}
+}
+
+// defaultConfigPath is trainticket/config.json in the user's config directory.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "trainticket", "config.json")
+}
+
+// loadConfig reads path over the defaults. A missing file is not an error
+// unless the path was given explicitly.
+func loadConfig(path string, explicit bool) (config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
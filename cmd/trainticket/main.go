@@ -0,0 +1,184 @@
+// Command trainticket is a command-line client for the TicketService.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// staffKeyEnv holds the staff key when it is not in the config file.
+	// Either keeps it out of shell history and process listings, unlike
+	// -staff-key.
+	staffKeyEnv = "TRAINTICKET_STAFF_KEY"
+
+	// userTokenEnv holds the passenger's user token in the same way.
+	userTokenEnv = "TRAINTICKET_USER_TOKEN"
+)
+
+// cli is what subcommands run against.
+type cli struct {
+	client    model.TicketServiceClient
+	out       *printer
+	staffKey  string // From the config file or staffKeyEnv
+	userToken string // From the config file or userTokenEnv
+}
+
+// staffFlag defines the -staff-key flag of a staff command.
+func (c *cli) staffFlag(fs *flag.FlagSet) *string {
+	return fs.String("staff-key", "", "staff key (default staff_key from the config file or $"+staffKeyEnv+")")
+}
+
+// asStaff returns ctx carrying key, or the configured staff key if key is
+// empty, as x-staff-key metadata.
+func (c *cli) asStaff(ctx context.Context, key string) context.Context {
+	if key == "" {
+		key = c.staffKey
+	}
+	return metadata.AppendToOutgoingContext(ctx, "x-staff-key", key)
+}
+
+// userFlag defines the -user-token flag of a command acting for a passenger.
+func (c *cli) userFlag(fs *flag.FlagSet) *string {
+	return fs.String("user-token", "", "user token (default user_token from the config file or $"+userTokenEnv+")")
+}
+
+// asUser returns ctx carrying token, or the configured user token if token
+// is empty, as x-user-token metadata. It carries nothing if neither is set.
+func (c *cli) asUser(ctx context.Context, token string) context.Context {
+	if token == "" {
+		token = c.userToken
+	}
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "x-user-token", token)
+}
+
+// command is one trainticket subcommand.
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"purchase":      {usage: "purchase -from CITY -to CITY (-email EMAIL [-first NAME -last NAME] | -user-id ID) [-user-token TOKEN] [-trip ID] [-assist NEED,...] [-class standard|first]", run: runPurchase},
+	"receipt":       {usage: "receipt TICKET", run: runReceipt},
+	"list-section":  {usage: "list-section [-section S] [-trip ID] [-sort seat|surname|ticket] [-email E] [-name PREFIX] [-page-size N] [-page-token T] [-staff-key KEY]", run: runListSection},
+	"remove":        {usage: "remove TICKET [-version V]", run: runRemove},
+	"modify-seat":   {usage: "modify-seat TICKET -section S [-seat SEAT] [-version V] [-user-token TOKEN | -staff-key KEY]", run: runModifySeat},
+	"change-class":  {usage: "change-class TICKET -class standard|first [-version V] [-user-token TOKEN | -staff-key KEY]", run: runChangeClass},
+	"exchange":      {usage: "exchange TICKET -trip ID [-class standard|first] [-version V] [-user-token TOKEN | -staff-key KEY]", run: runExchange},
+	"swap-consent":  {usage: "swap-consent TICKET -with TICKET [-user-token TOKEN]", run: runSwapConsent},
+	"swap":          {usage: "swap TICKET TICKET (-consent1 TOKEN -consent2 TOKEN | -override [-staff-key KEY])", run: runSwap},
+	"block":         {usage: "block (-seats SEAT,... | -section S) -reason R [-trip ID] [-from TIME] [-until TIME] [-staff-key KEY]", run: runBlock},
+	"unblock":       {usage: "unblock BLOCK [-trip ID] [-staff-key KEY]", run: runUnblock},
+	"blocks":        {usage: "blocks [-trip ID] [-staff-key KEY]", run: runBlocks},
+	"relocate":      {usage: "relocate SECTION -reason R [-trip ID] [-staff-key KEY]", run: runRelocate},
+	"check-in":      {usage: "check-in TICKET [-version V] [-user-token TOKEN | -staff-key KEY]", run: runCheckIn},
+	"board":         {usage: "board TICKET [-staff-key KEY]", run: runBoard},
+	"no-shows":      {usage: "no-shows [-trip ID] [-release] [-staff-key KEY]", run: runNoShows},
+	"boarding-pass": {usage: "boarding-pass TICKET [-size N] [-o FILE] [-user-token TOKEN | -staff-key KEY]", run: runBoardingPass},
+	"verify-pass":   {usage: "verify-pass TOKEN [-trip ID] [-staff-key KEY]", run: runVerifyPass},
+	"export-bundle": {usage: "export-bundle -key PUBLIC_KEY [-trip ID] [-o FILE] [-staff-key KEY]", run: runExportBundle},
+	"overbooking":   {usage: "overbooking [-trip ID] [-section S] [-percent N] [-staff-key KEY]", run: runOverbooking},
+	"offload":       {usage: "offload SECTION [-trip ID] [-rebook-trip ID] [-compensation AMOUNT] [-staff-key KEY]", run: runOffload},
+	"create-trip":   {usage: "create-trip ID -sections NAME:CLASS:SEATS,... [-departs TIME] [-staff-key KEY]", run: runCreateTrip},
+	"seatmap":       {usage: "seatmap [-trip ID]", run: runSeatMap},
+	"manifest":      {usage: "manifest [-trip ID] [-format csv|json|html] [-o FILE] [-staff-key KEY]", run: runManifest},
+}
+
+func main() {
+	global := flag.NewFlagSet("trainticket", flag.ExitOnError)
+	configPath := global.String("config", defaultConfigPath(), "config file")
+	addr := global.String("addr", "", "TicketService address (overrides config)")
+	timeout := global.Duration("timeout", 0, "per-call deadline (overrides config)")
+	output := global.String("output", "", "output format: table or json (overrides config)")
+	global.Usage = usage(global)
+	global.Parse(os.Args[1:])
+
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[global.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", global.Arg(0))
+		global.Usage()
+		os.Exit(2)
+	}
+
+	explicit := false
+	global.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "config" })
+	cfg, err := loadConfig(*configPath, explicit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if *addr != "" {
+		cfg.Addr = *addr
+	}
+	if *timeout != 0 {
+		cfg.Timeout = duration(*timeout)
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+	if key := os.Getenv(staffKeyEnv); key != "" {
+		cfg.StaffKey = key
+	}
+	if token := os.Getenv(userTokenEnv); token != "" {
+		cfg.UserToken = token
+	}
+	if cfg.Output != "table" && cfg.Output != "json" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", cfg.Output)
+		os.Exit(2)
+	}
+
+	conn, err := grpc.NewClient(cfg.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout))
+	defer cancel()
+	c := &cli{
+		client: model.NewTicketServiceClient(conn),
+		out:    &printer{w: os.Stdout, format: cfg.Output},
+
+		staffKey:  cfg.StaffKey,
+		userToken: cfg.UserToken,
+	}
+	if err := cmd.run(ctx, c, global.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", global.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usage(global *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintln(os.Stderr, "usage: trainticket [flags] <command> [args]")
+		fmt.Fprintln(os.Stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+		}
+		fmt.Fprintln(os.Stderr, "\nflags:")
+		global.PrintDefaults()
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/api"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func newTestCLI(t *testing.T, format string) (*cli, *bytes.Buffer) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer()
+	model.RegisterTicketServiceServer(server, api.NewTicketServiceServer())
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	var buf bytes.Buffer
+	return &cli{client: model.NewTicketServiceClient(conn), out: &printer{w: &buf, format: format}}, &buf
+}
+
+func TestCommandsTableOutput(t *testing.T) {
+	c, buf := newTestCLI(t, "table")
+	ctx := context.Background()
+
+	assert.NoError(t, runPurchase(ctx, c, []string{"-from", "City A", "-to", "City B", "-first", "Alice", "-last", "Doe", "-email", "alice@example.com"}))
+	// A new passenger is given the token for their profile
+	assert.Regexp(t, `^TICKET  TRIP     SECTION  SEAT\n1       default  A        1A\n\nYour user token, needed to check in or manage this ticket:\nU1\.\S+\n$`, buf.String())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	token := lines[len(lines)-1]
+
+	// Moving to a first class seat changes the fare, so it needs the token
+	buf.Reset()
+	assert.Error(t, runModifySeat(ctx, c, []string{"1", "-section", "B", "-seat", "2C"}))
+	assert.NoError(t, runModifySeat(ctx, c, []string{"1", "-section", "B", "-seat", "2C", "-user-token", token}))
+	assert.Equal(t, "User seat modified successfully.\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, runListSection(ctx, c, []string{"-section", "B"}))
+	assert.Contains(t, buf.String(), "2C    Alice Doe  alice@example.com")
+
+	buf.Reset()
+	assert.NoError(t, runRemove(ctx, c, []string{"1"}))
+	assert.Equal(t, "User removed successfully.\n", buf.String())
+
+	assert.EqualError(t, runReceipt(ctx, c, []string{"x"}), `invalid ticket number "x"`)
+}
+
+func TestCommandsJSONOutput(t *testing.T) {
+	c, buf := newTestCLI(t, "json")
+	ctx := context.Background()
+
+	assert.NoError(t, runSeatMap(ctx, c, nil))
+	var res model.GetSeatMapResponse
+	assert.NoError(t, protojson.Unmarshal(buf.Bytes(), &res))
+	assert.Len(t, res.Seats, 20)
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "missing.json"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultConfig(), cfg)
+
+	_, err = loadConfig(filepath.Join(t.TempDir(), "missing.json"), true)
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"addr": "tickets.example.com:443", "timeout": "3s", "staff_key": "secret", "user_token": "U1.sig"}`), 0o600))
+	cfg, err = loadConfig(path, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "tickets.example.com:443", cfg.Addr)
+	assert.Equal(t, duration(3*time.Second), cfg.Timeout)
+	assert.Equal(t, "table", cfg.Output)
+	assert.Equal(t, "secret", cfg.StaffKey)
+	assert.Equal(t, "U1.sig", cfg.UserToken)
+}
+
+func TestStaffKey(t *testing.T) {
+	c := &cli{staffKey: "configured"}
+	md, _ := metadata.FromOutgoingContext(c.asStaff(context.Background(), ""))
+	assert.Equal(t, []string{"configured"}, md.Get("x-staff-key"))
+	// The flag wins over the config file
+	md, _ = metadata.FromOutgoingContext(c.asStaff(context.Background(), "flag"))
+	assert.Equal(t, []string{"flag"}, md.Get("x-staff-key"))
+}
+
+func TestUserToken(t *testing.T) {
+	md, _ := metadata.FromOutgoingContext((&cli{}).asUser(context.Background(), ""))
+	assert.Empty(t, md.Get("x-user-token"))
+	c := &cli{userToken: "configured"}
+	md, _ = metadata.FromOutgoingContext(c.asUser(context.Background(), ""))
+	assert.Equal(t, []string{"configured"}, md.Get("x-user-token"))
+	md, _ = metadata.FromOutgoingContext(c.asUser(context.Background(), "flag"))
+	assert.Equal(t, []string{"flag"}, md.Get("x-user-token"))
+}
+
+func TestPublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	key, err := publicKey(base64.StdEncoding.EncodeToString(pub))
+	assert.NoError(t, err)
+	assert.True(t, pub.Equal(key))
+
+	_, err = publicKey("")
+	assert.Error(t, err)
+	_, err = publicKey("not base64")
+	assert.Error(t, err)
+	_, err = publicKey(base64.StdEncoding.EncodeToString(pub[:16]))
+	assert.Error(t, err)
+}
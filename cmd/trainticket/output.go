@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// printer writes responses as indented JSON or as aligned tables.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p *printer) json(msg proto.Message) error {
+	data, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(p.w, string(data))
+	return err
+}
+
+// table writes a header and rows separated by tabs, aligned into columns.
+func (p *printer) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// message prints responses that only carry a status message.
+func (p *printer) message(msg interface {
+	proto.Message
+	GetMessage() string
+}) error {
+	if p.format == "json" {
+		return p.json(msg)
+	}
+	_, err := fmt.Fprintln(p.w, msg.GetMessage())
+	return err
+}
+
+func (p *printer) tickets(msg proto.Message, tickets []*model.Ticket) error {
+	if p.format == "json" {
+		return p.json(msg)
+	}
+	rows := make([][]string, 0, len(tickets))
+	for _, t := range tickets {
+		rows = append(rows, []string{
+			fmt.Sprint(t.TicketNumber),
+			t.TripId,
+			t.Section,
+			t.SeatNumber,
+			strings.TrimSpace(t.User.GetFirstName() + " " + t.User.GetLastName()),
+			t.User.GetEmail(),
+			t.From,
+			t.To,
+			fmt.Sprintf("%.2f", t.PricePaid),
+			strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(t.Status.String(), "TICKET_STATUS_"), "_", " ")),
+			fmt.Sprint(t.Version),
+		})
+	}
+	return p.table([]string{"TICKET", "TRIP", "SECTION", "SEAT", "PASSENGER", "EMAIL", "FROM", "TO", "PRICE", "STATUS", "VERSION"}, rows)
+}
+
+func (p *printer) seatMap(res *model.GetSeatMapResponse) error {
+	if p.format == "json" {
+		return p.json(res)
+	}
+	rows := make([][]string, 0, len(res.Seats))
+	for _, s := range res.Seats {
+		rows = append(rows, []string{
+			s.Section,
+			s.SeatNumber,
+			fmt.Sprint(s.Row),
+			fmt.Sprint(s.Column),
+			strings.ToLower(strings.TrimPrefix(s.Status.String(), "SEAT_STATUS_")),
+			strings.Join(s.Attributes, ","),
+		})
+	}
+	return p.table([]string{"SECTION", "SEAT", "ROW", "COLUMN", "STATUS", "ATTRIBUTES"}, rows)
+}
+
+// seatBlocks prints seat blocks followed by the reassignments proposed for
+// tickets in blocked seats.
+func (p *printer) seatBlocks(msg proto.Message, blocks []*model.SeatBlock, proposals []*model.ReassignmentProposal) error {
+	if p.format == "json" {
+		return p.json(msg)
+	}
+	rows := make([][]string, 0, len(blocks))
+	for _, b := range blocks {
+		seats := b.Section
+		if seats == "" {
+			seats = strings.Join(b.SeatNumbers, ",")
+		}
+		until := "-"
+		if b.EndTime != nil {
+			until = b.EndTime.AsTime().Format(time.RFC3339)
+		}
+		rows = append(rows, []string{b.BlockId, seats, b.StartTime.AsTime().Format(time.RFC3339), until, b.Reason})
+	}
+	if err := p.table([]string{"BLOCK", "SEATS", "FROM", "UNTIL", "REASON"}, rows); err != nil {
+		return err
+	}
+	if len(proposals) == 0 {
+		return nil
+	}
+
+	rows = make([][]string, 0, len(proposals))
+	for _, r := range proposals {
+		proposed := r.ProposedSeatNumber
+		if proposed == "" {
+			proposed = "none free"
+		}
+		rows = append(rows, []string{fmt.Sprint(r.TicketNumber), r.CurrentSeatNumber, proposed})
+	}
+	fmt.Fprintln(p.w)
+	return p.table([]string{"TICKET", "BLOCKED SEAT", "PROPOSED SEAT"}, rows)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// userTokenMetadataKey is the request metadata key carrying the caller's
+	// user token, which proves their user ID.
+	userTokenMetadataKey = "x-user-token"
+
+	// staffKeyMetadataKey is the request metadata key carrying the staff key
+	// that authorizes privileged calls.
+	staffKeyMetadataKey = "x-staff-key"
+
+	// staffActor is the actor recorded in ticket history for staff changes.
+	staffActor = "staff"
+)
+
+// WithStaffKey sets the key staff send as x-staff-key metadata to make
+// privileged calls. Without a key, no call is treated as staff.
+func WithStaffKey(key string) Option {
+	return func(s *TicketServiceServer) { s.staffKey = key }
+}
+
+// WithUserTokenKey sets the key user tokens are signed with. The default is a
+// key generated at startup, so tokens issued before a restart are refused.
+func WithUserTokenKey(key []byte) Option {
+	return func(s *TicketServiceServer) { s.userTokenKey = key }
+}
+
+func newUserTokenKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// userToken returns the token that proves a caller is the user userID. It is
+// handed to the user when their profile is registered.
+func (s *TicketServiceServer) userToken(userID string) string {
+	return userID + "." + base64.RawURLEncoding.EncodeToString(s.userTokenMAC(userID))
+}
+
+func (s *TicketServiceServer) userTokenMAC(userID string) []byte {
+	mac := hmac.New(sha256.New, s.userTokenKey)
+	mac.Write([]byte(userID))
+	return mac.Sum(nil)
+}
+
+// userIDFromContext returns the caller's user ID, proven by the user token in
+// the request metadata.
+func (s *TicketServiceServer) userIDFromContext(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "missing request metadata")
+	}
+	tokens := md.Get(userTokenMetadataKey)
+	if len(tokens) == 0 || tokens[0] == "" {
+		return "", status.Errorf(codes.Unauthenticated, "missing %s metadata", userTokenMetadataKey)
+	}
+	id, sig, found := strings.Cut(tokens[0], ".")
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if !found || err != nil || !hmac.Equal(got, s.userTokenMAC(id)) {
+		return "", status.Errorf(codes.Unauthenticated, "invalid %s metadata", userTokenMetadataKey)
+	}
+	return id, nil
+}
+
+// isStaff reports whether the call carries the staff key.
+func (s *TicketServiceServer) isStaff(ctx context.Context) bool {
+	if s.staffKey == "" {
+		return false
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(staffKeyMetadataKey)
+	return len(keys) > 0 && subtle.ConstantTimeCompare([]byte(keys[0]), []byte(s.staffKey)) == 1
+}
+
+// requireStaff fails with PermissionDenied unless the call is made by staff.
+func (s *TicketServiceServer) requireStaff(ctx context.Context) error {
+	if !s.isStaff(ctx) {
+		return status.Errorf(codes.PermissionDenied, "staff only: missing or invalid %s metadata", staffKeyMetadataKey)
+	}
+	return nil
+}
+
+// requireOwnerOrStaff fails with PermissionDenied unless the call is made by
+// staff or by the user userID.
+func (s *TicketServiceServer) requireOwnerOrStaff(ctx context.Context, userID string) error {
+	if s.isStaff(ctx) {
+		return nil
+	}
+	if id, _ := s.userIDFromContext(ctx); id == "" || id != userID {
+		return status.Errorf(codes.PermissionDenied, "only user %s or staff may do this", userID)
+	}
+	return nil
+}
+
+// actor names the caller for ticket history: staff, the user its token
+// proves, or empty if it is anonymous.
+func (s *TicketServiceServer) actor(ctx context.Context) string {
+	if s.isStaff(ctx) {
+		return staffActor
+	}
+	id, _ := s.userIDFromContext(ctx)
+	return id
+}
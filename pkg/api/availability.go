@@ -0,0 +1,161 @@
+package api
+
+import (
+	"sync"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// availabilityHistory is how many past updates are kept for resuming
+	// watchers; older revisions are answered with a fresh snapshot.
+	availabilityHistory = 1024
+
+	// watcherBuffer is how many updates a watcher may fall behind before its
+	// stream is ended and it has to resume.
+	watcherBuffer = 64
+)
+
+// availabilityFeed publishes seat availability changes to watchers. Changes
+// are published while the changed trip is still locked, so revisions follow
+// inventory changes exactly.
+type availabilityFeed struct {
+	mu       sync.Mutex
+	revision int64
+	history  []*model.AvailabilityUpdate
+	watchers map[chan *model.AvailabilityUpdate]struct{}
+}
+
+func newAvailabilityFeed() *availabilityFeed {
+	return &availabilityFeed{watchers: make(map[chan *model.AvailabilityUpdate]struct{})}
+}
+
+// publish records changes as the next revision and fans them out. Watchers
+// whose buffer is full are dropped. Callers must hold the write lock of the
+// trips the changes are on.
+func (f *availabilityFeed) publish(changes ...*model.SeatChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.revision++
+	update := &model.AvailabilityUpdate{Revision: f.revision, Changes: changes}
+	f.history = append(f.history, update)
+	if len(f.history) > availabilityHistory {
+		f.history = f.history[len(f.history)-availabilityHistory:]
+	}
+	for ch := range f.watchers {
+		select {
+		case ch <- update:
+		default:
+			delete(f.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// since returns the updates published after revision, or false if some of
+// them are no longer retained. Callers must hold f.mu.
+func (f *availabilityFeed) since(revision int64) ([]*model.AvailabilityUpdate, bool) {
+	if revision <= 0 || revision > f.revision {
+		return nil, false
+	}
+	if revision == f.revision {
+		return nil, true
+	}
+	if len(f.history) == 0 || f.history[0].Revision > revision+1 {
+		return nil, false
+	}
+	return f.history[revision+1-f.history[0].Revision:], true
+}
+
+// WatchAvailability implementation
+func (s *TicketServiceServer) WatchAvailability(req *model.WatchAvailabilityRequest, stream grpc.ServerStreamingServer[model.AvailabilityUpdate]) error {
+	trips := s.allTrips()
+	if req.TripId != "" {
+		t, err := s.trip(req.TripId)
+		if err != nil {
+			return err
+		}
+		trips = []*trip{t}
+	}
+
+	// Holding the watched trips stops them changing between the snapshot and
+	// the subscription.
+	unlock := lockTrips(trips, false)
+	s.feed.mu.Lock()
+	backlog, ok := s.feed.since(req.SinceRevision)
+	if !ok {
+		backlog = []*model.AvailabilityUpdate{availabilitySnapshot(trips, s.feed.revision)}
+	}
+	updates := make(chan *model.AvailabilityUpdate, watcherBuffer)
+	s.feed.watchers[updates] = struct{}{}
+	s.feed.mu.Unlock()
+	unlock()
+
+	defer func() {
+		s.feed.mu.Lock()
+		if _, exists := s.feed.watchers[updates]; exists {
+			delete(s.feed.watchers, updates)
+			close(updates)
+		}
+		s.feed.mu.Unlock()
+	}()
+
+	send := func(update *model.AvailabilityUpdate) error {
+		if update = forTrip(update, req.TripId); update == nil {
+			return nil
+		}
+		return stream.Send(update)
+	}
+	for _, update := range backlog {
+		if err := send(update); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return status.Error(codes.Aborted, "watcher fell behind; resume from the last revision received")
+			}
+			if err := send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forTrip narrows update to the changes on tripID, returning nil if none are
+// left. An empty tripID keeps every change.
+func forTrip(update *model.AvailabilityUpdate, tripID string) *model.AvailabilityUpdate {
+	if tripID == "" || update.Snapshot {
+		return update
+	}
+	var changes []*model.SeatChange
+	for _, change := range update.Changes {
+		if change.TripId == tripID {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return &model.AvailabilityUpdate{Revision: update.Revision, Changes: changes}
+}
+
+// availabilitySnapshot returns every seat of trips with its availability at
+// revision. Callers must hold the trips' locks.
+func availabilitySnapshot(trips []*trip, revision int64) *model.AvailabilityUpdate {
+	var changes []*model.SeatChange
+	for _, t := range trips {
+		for _, st := range t.seats {
+			changes = append(changes, &model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number, Available: st.free()})
+		}
+	}
+	return &model.AvailabilityUpdate{Revision: revision, Snapshot: true, Changes: changes}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// fakeWatchStream collects updates sent by WatchAvailability.
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	updates chan *model.AvailabilityUpdate
+}
+
+func newFakeWatchStream(ctx context.Context) *fakeWatchStream {
+	return &fakeWatchStream{ctx: ctx, updates: make(chan *model.AvailabilityUpdate, 16)}
+}
+
+func (f *fakeWatchStream) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchStream) Send(update *model.AvailabilityUpdate) error {
+	f.updates <- update
+	return nil
+}
+
+func (f *fakeWatchStream) next(t *testing.T) *model.AvailabilityUpdate {
+	select {
+	case update := <-f.updates:
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for availability update")
+		return nil
+	}
+}
+
+// purchaseForTest buys a ticket for Alice.
+func purchaseForTest(server *TicketServiceServer) *model.PurchaseResponse {
+	req := &model.PurchaseRequest{
+		From: "City A",
+		To:   "City B",
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	}
+	res, _ := server.PurchaseTicket(ownerContext(server, req), req)
+	return res
+}
+
+// ownerContext signs in as the owner of the profile a purchase names, if it
+// is registered, so tests can buy repeatedly for one passenger.
+func ownerContext(server *TicketServiceServer, req *model.PurchaseRequest) context.Context {
+	server.usersMu.RLock()
+	defer server.usersMu.RUnlock()
+	if id, found := server.findPurchaser(req); found {
+		return asUser(server, id)
+	}
+	return context.Background()
+}
+
+func TestWatchAvailabilityStreamsDeltas(t *testing.T) {
+	server := NewTicketServiceServer()
+	purchaseForTest(server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newFakeWatchStream(ctx)
+	done := make(chan error)
+	go func() { done <- server.WatchAvailability(&model.WatchAvailabilityRequest{}, stream) }()
+
+	snapshot := stream.next(t)
+	assert.True(t, snapshot.Snapshot)
+	assert.Equal(t, int64(1), snapshot.Revision)
+	assert.Len(t, snapshot.Changes, 20)
+	assert.Equal(t, &model.SeatChange{TripId: defaultTripID, Section: "A", SeatNumber: "1A"}, snapshot.Changes[0])
+
+	res := purchaseForTest(server)
+	update := stream.next(t)
+	assert.Equal(t, int64(2), update.Revision)
+	assert.Equal(t, []*model.SeatChange{{TripId: defaultTripID, Section: "A", SeatNumber: res.SeatNumber}}, update.Changes)
+
+	_, _ = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1J"})
+	update = stream.next(t)
+	assert.Equal(t, int64(3), update.Revision)
+	assert.Equal(t, []*model.SeatChange{
+		{TripId: defaultTripID, Section: "A", SeatNumber: res.SeatNumber, Available: true},
+		{TripId: defaultTripID, Section: "A", SeatNumber: "1J"},
+	}, update.Changes)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestWatchAvailabilityResumesFromRevision(t *testing.T) {
+	server := NewTicketServiceServer()
+	for i := 0; i < 3; i++ {
+		purchaseForTest(server)
+	}
+	_, _ = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := newFakeWatchStream(ctx)
+	go server.WatchAvailability(&model.WatchAvailabilityRequest{SinceRevision: 2}, stream)
+
+	update := stream.next(t)
+	assert.False(t, update.Snapshot)
+	assert.Equal(t, int64(3), update.Revision)
+	update = stream.next(t)
+	assert.Equal(t, int64(4), update.Revision)
+	assert.Equal(t, []*model.SeatChange{{TripId: defaultTripID, Section: "A", SeatNumber: "1B", Available: true}}, update.Changes)
+
+	// A revision from the future cannot be resumed and gets a snapshot.
+	stream = newFakeWatchStream(ctx)
+	go server.WatchAvailability(&model.WatchAvailabilityRequest{SinceRevision: 99}, stream)
+	update = stream.next(t)
+	assert.True(t, update.Snapshot)
+	assert.Equal(t, int64(4), update.Revision)
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// seatBlock takes seats out of service between start and end.
+type seatBlock struct {
+	id        string
+	section   string  // Set when the whole section is blocked
+	seats     []*seat // The blocked seats
+	reason    string
+	start     time.Time
+	end       time.Time // Zero for a block that lasts until lifted
+	createdBy string
+	timers    []*time.Timer // Fire when the window opens and closes
+	lifted    bool          // Set once removed by UnblockSeats
+}
+
+// active reports whether the block is in effect at now.
+func (b *seatBlock) active(now time.Time) bool {
+	return !now.Before(b.start) && !b.ended(now)
+}
+
+// ended reports whether the block's window is over at now.
+func (b *seatBlock) ended(now time.Time) bool {
+	return !b.end.IsZero() && !now.Before(b.end)
+}
+
+func (b *seatBlock) proto(tripID string) *model.SeatBlock {
+	out := &model.SeatBlock{
+		BlockId:   b.id,
+		TripId:    tripID,
+		Section:   b.section,
+		Reason:    b.reason,
+		StartTime: timestamppb.New(b.start),
+		CreatedBy: b.createdBy,
+	}
+	if b.section == "" {
+		for _, st := range b.seats {
+			out.SeatNumbers = append(out.SeatNumbers, st.number)
+		}
+	}
+	if !b.end.IsZero() {
+		out.EndTime = timestamppb.New(b.end)
+	}
+	return out
+}
+
+// blocked reports whether a block covering the seat is in effect at now.
+func (st *seat) blocked(now time.Time) bool {
+	for _, b := range st.blocks {
+		if b.active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// BlockSeats implementation
+func (s *TicketServiceServer) BlockSeats(ctx context.Context, req *model.BlockSeatsRequest) (*model.BlockSeatsResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return idempotent(ctx, s.idempotency, "BlockSeats", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.BlockSeatsResponse, error) {
+		return s.blockSeats(ctx, req)
+	})
+}
+
+func (s *TicketServiceServer) blockSeats(ctx context.Context, req *model.BlockSeatsRequest) (*model.BlockSeatsResponse, error) {
+	if (len(req.SeatNumbers) == 0) == (req.Section == "") {
+		return nil, status.Error(codes.InvalidArgument, "name either seat_numbers or a section")
+	}
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+	now := time.Now()
+	start := now
+	if req.StartTime != nil {
+		start = req.StartTime.AsTime()
+	}
+	var end time.Time
+	if req.EndTime != nil {
+		end = req.EndTime.AsTime()
+		if !end.After(start) || !end.After(now) {
+			return nil, status.Error(codes.InvalidArgument, "end_time must be after start_time and in the future")
+		}
+	}
+
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var seats []*seat
+	if req.Section != "" {
+		if _, exists := t.classes[req.Section]; !exists {
+			return nil, status.Errorf(codes.NotFound, "section not found: %s", req.Section)
+		}
+		for _, st := range t.seats {
+			if st.section == req.Section {
+				seats = append(seats, st)
+			}
+		}
+	} else {
+		for _, number := range req.SeatNumbers {
+			st := t.byNumber[number]
+			if st == nil {
+				return nil, status.Errorf(codes.NotFound, "seat not found: %s", number)
+			}
+			if !slices.Contains(seats, st) {
+				seats = append(seats, st)
+			}
+		}
+	}
+
+	b := &seatBlock{
+		section:   req.Section,
+		seats:     seats,
+		reason:    req.Reason,
+		start:     start,
+		end:       end,
+		createdBy: s.actor(ctx),
+	}
+	if changes := t.addBlock(b, now); len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	s.scheduleBlockEdges(t, b, now)
+
+	return &model.BlockSeatsResponse{
+		Block:     b.proto(t.id),
+		Proposals: t.proposeReassignments(seats, b),
+	}, nil
+}
+
+// UnblockSeats implementation
+func (s *TicketServiceServer) UnblockSeats(ctx context.Context, req *model.UnblockSeatsRequest) (*model.UnblockSeatsResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := slices.IndexFunc(t.blocks, func(b *seatBlock) bool { return b.id == req.BlockId })
+	if i < 0 {
+		return nil, status.Errorf(codes.NotFound, "block not found: %s", req.BlockId)
+	}
+	b := t.blocks[i]
+	t.blocks = slices.Delete(t.blocks, i, i+1)
+	b.lifted = true
+	for _, timer := range b.timers {
+		timer.Stop()
+	}
+	var changes []*model.SeatChange
+	for _, st := range b.seats {
+		wasFree := st.free()
+		st.blocks = slices.DeleteFunc(st.blocks, func(other *seatBlock) bool { return other == b })
+		if !wasFree && st.free() {
+			changes = append(changes, &model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number, Available: true})
+		}
+	}
+	if len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	s.serveWaitlist(ctx, t)
+
+	return &model.UnblockSeatsResponse{Message: "Seats unblocked successfully."}, nil
+}
+
+// ListSeatBlocks implementation
+func (s *TicketServiceServer) ListSeatBlocks(ctx context.Context, req *model.ListSeatBlocksRequest) (*model.ListSeatBlocksResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	now := time.Now()
+	res := &model.ListSeatBlocksResponse{}
+	for _, b := range t.blocks {
+		if !b.ended(now) {
+			res.Blocks = append(res.Blocks, b.proto(t.id))
+		}
+	}
+	var blocked []*seat
+	for _, st := range t.seats {
+		if st.blocked(now) {
+			blocked = append(blocked, st)
+		}
+	}
+	res.Proposals = t.proposeReassignments(blocked, nil)
+	return res, nil
+}
+
+// addBlock numbers b and places it on its seats, dropping blocks that have
+// ended. It returns the availability changes to publish. Callers must hold
+// t.mu for writing.
+func (t *trip) addBlock(b *seatBlock, now time.Time) []*model.SeatChange {
+	t.pruneBlocks(now)
+	t.lastBlock++
+	b.id = fmt.Sprintf("blk-%d", t.lastBlock)
+	var changes []*model.SeatChange
+	for _, st := range b.seats {
+		wasFree := st.free()
+		st.blocks = append(st.blocks, b)
+		if wasFree && !st.free() {
+			changes = append(changes, &model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number})
+		}
+	}
+	t.blocks = append(t.blocks, b)
+	return changes
+}
+
+// scheduleBlockEdges arranges for the opening and closing of b's window, if
+// still to come, to be acted on when they happen. Callers must hold t.mu for
+// writing.
+func (s *TicketServiceServer) scheduleBlockEdges(t *trip, b *seatBlock, now time.Time) {
+	for _, edge := range []time.Time{b.start, b.end} {
+		if edge.After(now) {
+			b.timers = append(b.timers, time.AfterFunc(edge.Sub(now), func() { s.blockEdge(t, b, edge) }))
+		}
+	}
+}
+
+// blockEdge publishes the availability of b's seats that changed as its
+// window opened or closed at edge, and serves the waitlist from any seats it
+// released.
+func (s *TicketServiceServer) blockEdge(t *trip, b *seatBlock, edge time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if b.lifted {
+		return
+	}
+	// b is left out of the seats' blocks once pruned, so it is checked apart
+	before := edge.Add(-time.Nanosecond)
+	var changes []*model.SeatChange
+	for _, st := range b.seats {
+		was := st.freeAt(before) && !b.active(before)
+		is := st.freeAt(edge) && !b.active(edge)
+		if was != is {
+			changes = append(changes, &model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number, Available: is})
+		}
+	}
+	if b.ended(edge) {
+		t.pruneBlocks(edge)
+	}
+	if len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	s.serveWaitlist(context.Background(), t)
+}
+
+// pruneBlocks drops blocks that have ended. Callers must hold t.mu for
+// writing.
+func (t *trip) pruneBlocks(now time.Time) {
+	t.blocks = slices.DeleteFunc(t.blocks, func(b *seatBlock) bool { return b.ended(now) })
+	for _, st := range t.seats {
+		st.blocks = slices.DeleteFunc(st.blocks, func(b *seatBlock) bool { return b.ended(now) })
+	}
+}
+
+// proposeReassignments suggests a seat for each ticket holding one of seats:
+// a free seat of the same travel class outside the block avoid, preferring
+// the ticket's own section. No seat is proposed twice. Callers must hold t.mu.
+func (t *trip) proposeReassignments(seats []*seat, avoid *seatBlock) []*model.ReassignmentProposal {
+	var proposals []*model.ReassignmentProposal
+	taken := make(map[*seat]bool)
+	for _, from := range seats {
+		if from.ticket == 0 {
+			continue
+		}
+		proposal := &model.ReassignmentProposal{
+			TicketNumber:      from.ticket,
+			CurrentSeatNumber: from.number,
+			CurrentSection:    from.section,
+		}
+		if to := t.alternativeSeat(from, taken, avoid); to != nil {
+			taken[to] = true
+			proposal.ProposedSeatNumber = to.number
+			proposal.ProposedSection = to.section
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals
+}
+
+// alternativeSeat returns a free seat of from's travel class that is neither
+// taken nor covered by avoid, preferring from's section, or nil if there is
+// none. Callers must hold t.mu.
+func (t *trip) alternativeSeat(from *seat, taken map[*seat]bool, avoid *seatBlock) *seat {
+	var sameClass *seat
+	for _, st := range t.seats {
+		if !st.free() || taken[st] || slices.Contains(st.blocks, avoid) {
+			continue
+		}
+		if st.section == from.section {
+			return st
+		}
+		if sameClass == nil && t.classes[st.section] == t.classes[from.section] {
+			sameClass = st
+		}
+	}
+	return sameClass
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func asStaff(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(staffKeyMetadataKey, key))
+}
+
+func TestBlockSeatsSkippedByPurchaseAndModify(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	first := purchaseForTest(server)
+
+	_, err := server.BlockSeats(context.Background(), &model.BlockSeatsRequest{SeatNumbers: []string{"1B"}, Reason: "broken"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{SeatNumbers: []string{"1B"}, Section: "A", Reason: "broken"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	block, err := server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{SeatNumbers: []string{"1B", "1C"}, Reason: "broken recline"})
+	assert.NoError(t, err)
+	assert.Equal(t, "blk-1", block.Block.BlockId)
+	assert.Equal(t, []string{"1B", "1C"}, block.Block.SeatNumbers)
+	assert.Equal(t, staffActor, block.Block.CreatedBy)
+	assert.Empty(t, block.Proposals)
+
+	second := purchaseForTest(server)
+	assert.Equal(t, "1D", second.SeatNumber)
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: first.TicketNumber, NewSeatNumber: "1C"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	seatMap, _ := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{})
+	assert.Equal(t, model.SeatStatus_SEAT_STATUS_BLOCKED, seatMap.Seats[1].Status)
+
+	_, err = server.UnblockSeats(asStaff("secret"), &model.UnblockSeatsRequest{BlockId: "blk-1"})
+	assert.NoError(t, err)
+	_, err = server.UnblockSeats(asStaff("secret"), &model.UnblockSeatsRequest{BlockId: "blk-1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "1B", purchaseForTest(server).SeatNumber)
+}
+
+func TestBlockSeatsTimeWindow(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+
+	_, err := server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{
+		Section:   "A",
+		Reason:    "cleaning",
+		StartTime: timestamppb.New(time.Now().Add(time.Hour)),
+		EndTime:   timestamppb.New(time.Now().Add(2 * time.Hour)),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "1A", purchaseForTest(server).SeatNumber)
+
+	_, err = server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{
+		Section:   "A",
+		Reason:    "cleaning",
+		StartTime: timestamppb.New(time.Now().Add(-time.Hour)),
+		EndTime:   timestamppb.New(time.Now().Add(-time.Minute)),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	blocks, err := server.ListSeatBlocks(asStaff("secret"), &model.ListSeatBlocksRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, blocks.Blocks, 1)
+	assert.Empty(t, blocks.Proposals)
+}
+
+func TestBlockSeatsWindowEdges(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C", "D"}, map[string][]string{"C": {"3A"}, "D": {"4A", "4B"}}))
+	lastChange := func() *model.SeatChange {
+		server.feed.mu.Lock()
+		defer server.feed.mu.Unlock()
+		changes := server.feed.history[len(server.feed.history)-1].Changes
+		return changes[len(changes)-1]
+	}
+	block := func(seat string, start, end time.Time) {
+		req := &model.BlockSeatsRequest{TripId: "T2", SeatNumbers: []string{seat}, Reason: "cleaning", StartTime: timestamppb.New(start)}
+		if !end.IsZero() {
+			req.EndTime = timestamppb.New(end)
+		}
+		_, err := server.BlockSeats(asStaff("secret"), req)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now()
+	block("4B", now.Add(50*time.Millisecond), time.Time{})
+	block("4A", now, now.Add(300*time.Millisecond))
+	// The seat is announced as taken once its block starts
+	assert.Eventually(t, func() bool {
+		change := lastChange()
+		return change.SeatNumber == "4B" && !change.Available
+	}, time.Second, 5*time.Millisecond)
+
+	alice, err := purchaseOnTrip(server, "T2", "Alice")
+	assert.NoError(t, err)
+	_, err = server.RelocateSection(asStaff("secret"), &model.RelocateSectionRequest{TripId: "T2", Section: "C", Reason: "coach fault"})
+	assert.NoError(t, err)
+	assert.Empty(t, receiptFor(server, alice.TicketNumber).SeatNumber)
+
+	// When the other block ends its seat goes to the waitlist without anyone
+	// lifting it
+	assert.Eventually(t, func() bool {
+		return receiptFor(server, alice.TicketNumber).SeatNumber == "4A"
+	}, time.Second, 5*time.Millisecond)
+	blocks, err := server.ListSeatBlocks(asStaff("secret"), &model.ListSeatBlocksRequest{TripId: "T2"})
+	assert.NoError(t, err)
+	assert.Len(t, blocks.Blocks, 2)
+}
+
+func TestBlockOccupiedSeatsProposesReassignment(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	for i := 0; i < 9; i++ {
+		purchaseForTest(server)
+	}
+
+	// Section A has one seat left, so the second passenger is offered nothing
+	// in first class B
+	block, err := server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{SeatNumbers: []string{"1A", "1B"}, Reason: "water leak"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.ReassignmentProposal{
+		{TicketNumber: 1, CurrentSeatNumber: "1A", CurrentSection: "A", ProposedSeatNumber: "1J", ProposedSection: "A"},
+		{TicketNumber: 2, CurrentSeatNumber: "1B", CurrentSection: "A"},
+	}, block.Proposals)
+
+	// Tickets in blocked seats cannot be swapped into other passengers' seats
+	_, err = server.SwapSeats(asStaff("secret"), &model.SwapSeatsRequest{FirstTicketNumber: 1, SecondTicketNumber: 3, StaffOverride: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: 1, NewSeatNumber: "1J"})
+	assert.NoError(t, err)
+	blocks, err := server.ListSeatBlocks(asStaff("secret"), &model.ListSeatBlocksRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.ReassignmentProposal{{TicketNumber: 2, CurrentSeatNumber: "1B", CurrentSection: "A"}}, blocks.Proposals)
+}
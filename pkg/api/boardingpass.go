@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultQRSize is the width of boarding pass QR codes, in pixels, unless
+// the request sets one.
+const defaultQRSize = 256
+
+// WithBoardingPassKey sets the key boarding passes are signed with. The
+// default is a key generated at startup, so passes issued before a restart
+// no longer verify.
+func WithBoardingPassKey(key ed25519.PrivateKey) Option {
+	return func(s *TicketServiceServer) { s.boardingPassKey = key }
+}
+
+func newBoardingPassKey() ed25519.PrivateKey {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	return key
+}
+
+// GetBoardingPass implementation
+func (s *TicketServiceServer) GetBoardingPass(ctx context.Context, req *model.GetBoardingPassRequest) (*model.GetBoardingPassResponse, error) {
+	size := int(req.QrSize)
+	if size < 0 || size > 2048 {
+		return nil, status.Error(codes.InvalidArgument, "qr_size must be between 0 and 2048")
+	}
+	if size == 0 {
+		size = defaultQRSize
+	}
+
+	_, ticket, unlock := s.lockTicket(req.TicketNumber, false)
+	if ticket == nil {
+		return nil, status.Errorf(codes.NotFound, "ticket not found: %d", req.TicketNumber)
+	}
+	defer unlock()
+	if err := s.requireOwnerOrStaff(ctx, ticket.UserId); err != nil {
+		return nil, err
+	}
+	switch ticket.Status {
+	case model.TicketStatus_TICKET_STATUS_CHECKED_IN, model.TicketStatus_TICKET_STATUS_BOARDED:
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition, "ticket %d is %s; boarding passes are issued at check-in", req.TicketNumber, statusName(ticket.Status))
+	}
+
+	user := s.withUser(ticket).User
+	pass := &model.BoardingPass{
+		TicketNumber:  ticket.TicketNumber,
+		TripId:        ticket.TripId,
+		Section:       ticket.Section,
+		SeatNumber:    ticket.SeatNumber,
+		PassengerName: strings.TrimSpace(user.GetFirstName() + " " + user.GetLastName()),
+		TravelClass:   ticket.TravelClass,
+		From:          ticket.From,
+		To:            ticket.To,
+		IssuedAt:      time.Now().Unix(),
+	}
+	token, err := boardingpass.Sign(s.boardingPassKey, pass)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign boarding pass: %v", err)
+	}
+	qr, err := boardingpass.QRCode(token, size)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to render boarding pass: %v", err)
+	}
+
+	return &model.GetBoardingPassResponse{Pass: pass, Token: token, QrPng: qr}, nil
+}
+
+// VerifyBoardingPass implementation
+func (s *TicketServiceServer) VerifyBoardingPass(ctx context.Context, req *model.VerifyBoardingPassRequest) (*model.VerifyBoardingPassResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+
+	// A pass that fails verification is a verdict, not an error
+	pass, err := boardingpass.Verify(req.Token, s.boardingPassKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		return &model.VerifyBoardingPassResponse{Reason: err.Error()}, nil
+	}
+	res := &model.VerifyBoardingPassResponse{Pass: pass}
+	if pass.TripId != t.id {
+		res.Reason = fmt.Sprintf("boarding pass is for trip %s", pass.TripId)
+		return res, nil
+	}
+
+	ticket, unlock := s.lockReceipt(pass.TicketNumber)
+	if ticket == nil {
+		res.Reason = "ticket not found"
+		return res, nil
+	}
+	defer unlock()
+	res.Status = ticket.Status
+	res.Reason = boardingpass.Check(pass, t.id, ticket.Status, ticket.SeatNumber)
+	res.Valid = res.Reason == ""
+	return res, nil
+}
+
+// GetBoardingPassKey implementation
+func (s *TicketServiceServer) GetBoardingPassKey(ctx context.Context, req *model.GetBoardingPassKeyRequest) (*model.GetBoardingPassKeyResponse, error) {
+	pub := s.boardingPassKey.Public().(ed25519.PublicKey)
+	return &model.GetBoardingPassKeyResponse{KeyId: boardingpass.KeyID(pub), PublicKey: pub}, nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBoardingPass(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	res := purchaseForTest(server)
+
+	_, err := server.GetBoardingPass(ownerOf(server, res.TicketNumber), &model.GetBoardingPassRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = server.CheckIn(ownerOf(server, res.TicketNumber), &model.CheckInRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	// Only the owner or staff may get the pass
+	_, err = server.GetBoardingPass(context.Background(), &model.GetBoardingPassRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.GetBoardingPass(asUser(server, "U2"), &model.GetBoardingPassRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.GetBoardingPass(asStaff("secret"), &model.GetBoardingPassRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	pass, err := server.GetBoardingPass(ownerOf(server, res.TicketNumber), &model.GetBoardingPassRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	assert.Equal(t, "1A", pass.Pass.SeatNumber)
+	assert.Equal(t, "Alice Doe", pass.Pass.PassengerName)
+	assert.NotEmpty(t, pass.QrPng)
+
+	// Scanners can check the signature offline with the published key
+	key, err := server.GetBoardingPassKey(context.Background(), &model.GetBoardingPassKeyRequest{})
+	assert.NoError(t, err)
+	offline, err := boardingpass.Verify(pass.Token, ed25519.PublicKey(key.PublicKey))
+	assert.NoError(t, err)
+	assert.Equal(t, key.KeyId, offline.KeyId)
+
+	_, err = server.VerifyBoardingPass(context.Background(), &model.VerifyBoardingPassRequest{Token: pass.Token})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	verdict, err := server.VerifyBoardingPass(asStaff("secret"), &model.VerifyBoardingPassRequest{Token: pass.Token})
+	assert.NoError(t, err)
+	assert.True(t, verdict.Valid)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CHECKED_IN, verdict.Status)
+
+	verdict, _ = server.VerifyBoardingPass(asStaff("secret"), &model.VerifyBoardingPassRequest{Token: pass.Token, TripId: "T2"})
+	assert.False(t, verdict.Valid)
+	assert.Equal(t, "boarding pass is for trip default", verdict.Reason)
+	verdict, _ = server.VerifyBoardingPass(asStaff("secret"), &model.VerifyBoardingPassRequest{Token: pass.Token + "x"})
+	assert.False(t, verdict.Valid)
+	assert.Equal(t, "invalid boarding pass signature", verdict.Reason)
+
+	// A pass goes stale when the seat changes, and is refused once cancelled
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1B"})
+	assert.NoError(t, err)
+	verdict, _ = server.VerifyBoardingPass(asStaff("secret"), &model.VerifyBoardingPassRequest{Token: pass.Token})
+	assert.False(t, verdict.Valid)
+	assert.Equal(t, "ticket has moved to seat 1B; the boarding pass must be reissued", verdict.Reason)
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	verdict, _ = server.VerifyBoardingPass(asStaff("secret"), &model.VerifyBoardingPassRequest{Token: pass.Token})
+	assert.False(t, verdict.Valid)
+	assert.Equal(t, "ticket is cancelled", verdict.Reason)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CANCELLED, verdict.Status)
+}
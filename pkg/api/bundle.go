@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"slices"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportValidationBundle implementation
+func (s *TicketServiceServer) ExportValidationBundle(ctx context.Context, req *model.ExportValidationBundleRequest) (*model.ExportValidationBundleResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &model.ValidationBundle{TripId: t.id}
+	t.mu.RLock()
+	bundle.GeneratedAt = time.Now().Unix()
+	for _, ticket := range t.tickets {
+		switch ticket.Status {
+		case model.TicketStatus_TICKET_STATUS_CHECKED_IN, model.TicketStatus_TICKET_STATUS_BOARDED:
+			bundle.Tickets = append(bundle.Tickets, &model.BundleTicket{
+				TicketNumber: ticket.TicketNumber,
+				SeatNumber:   ticket.SeatNumber,
+				Status:       ticket.Status,
+			})
+		}
+	}
+	t.mu.RUnlock()
+	slices.SortFunc(bundle.Tickets, func(a, b *model.BundleTicket) int { return int(a.TicketNumber - b.TicketNumber) })
+
+	data, sig, err := boardingpass.SignBundle(s.boardingPassKey, bundle)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign validation bundle: %v", err)
+	}
+	return &model.ExportValidationBundleResponse{Bundle: data, Signature: sig}, nil
+}
+
+// SyncScans implementation
+func (s *TicketServiceServer) SyncScans(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return idempotent(ctx, s.idempotency, "SyncScans", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.SyncScansResponse, error) {
+		return s.syncScans(ctx, req)
+	})
+}
+
+func (s *TicketServiceServer) syncScans(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error) {
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := &model.SyncScansResponse{}
+	actor := s.actor(ctx)
+	for _, scan := range req.Scans {
+		result := &model.ScanResult{TicketNumber: scan.TicketNumber}
+		res.Results = append(res.Results, result)
+		s.syncScan(t, scan, result, actor)
+	}
+	return res, nil
+}
+
+// syncScan boards the passenger of one scan if the pass was accepted on
+// board and is still genuine, recording the outcome in result. The trip
+// must be write locked.
+func (s *TicketServiceServer) syncScan(t *trip, scan *model.Scan, result *model.ScanResult, actor string) {
+	if !scan.Valid {
+		result.Reason = "refused on board: " + scan.Reason
+		return
+	}
+	// The scanner's verdict is not trusted; only the signature is
+	pass, err := boardingpass.Verify(scan.Token, s.boardingPassKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		result.Reason = err.Error()
+		return
+	}
+	result.TicketNumber = pass.TicketNumber
+	if pass.TripId != t.id {
+		result.Reason = "boarding pass is for trip " + pass.TripId
+		return
+	}
+
+	ticket := t.tickets[pass.TicketNumber]
+	if ticket == nil {
+		ticket = t.receipts[pass.TicketNumber]
+	}
+	switch {
+	case ticket == nil:
+		result.Reason = "ticket not found"
+	case ticket.Status == model.TicketStatus_TICKET_STATUS_BOARDED:
+		result.Boarded = true
+	case checkTransition(ticket, model.TicketStatus_TICKET_STATUS_BOARDED) != nil:
+		result.Reason = "ticket is " + statusName(ticket.Status)
+	default:
+		// The passenger is on the train, even if the seat changed since
+		note := "scanned offline"
+		if scan.ScannedAt != nil {
+			note += " at " + scan.ScannedAt.AsTime().UTC().Format(time.DateTime)
+		}
+		setStatus(ticket, model.TicketStatus_TICKET_STATUS_BOARDED, actor, note)
+		ticket.Version++
+		result.Boarded = true
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExportValidationBundle(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	purchaseForTest(server)
+	checkedIn := purchaseForTest(server)
+	_, err := server.CheckIn(ownerOf(server, checkedIn.TicketNumber), &model.CheckInRequest{TicketNumber: checkedIn.TicketNumber})
+	assert.NoError(t, err)
+
+	_, err = server.ExportValidationBundle(context.Background(), &model.ExportValidationBundleRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	res, err := server.ExportValidationBundle(asStaff("secret"), &model.ExportValidationBundleRequest{})
+	assert.NoError(t, err)
+
+	key, _ := server.GetBoardingPassKey(context.Background(), &model.GetBoardingPassKeyRequest{})
+	bundle, err := boardingpass.OpenBundle(res.Bundle, res.Signature, ed25519.PublicKey(key.PublicKey))
+	assert.NoError(t, err)
+	assert.Equal(t, "default", bundle.TripId)
+	assert.Equal(t, key.KeyId, bundle.KeyId)
+	// Only tickets that can travel are included
+	assert.Len(t, bundle.Tickets, 1)
+	assert.Equal(t, checkedIn.TicketNumber, bundle.Tickets[0].TicketNumber)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CHECKED_IN, bundle.Tickets[0].Status)
+}
+
+func TestSyncScans(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	var tokens []string
+	for range 3 {
+		res := purchaseForTest(server)
+		_, err := server.CheckIn(ownerOf(server, res.TicketNumber), &model.CheckInRequest{TicketNumber: res.TicketNumber})
+		assert.NoError(t, err)
+		pass, err := server.GetBoardingPass(ownerOf(server, res.TicketNumber), &model.GetBoardingPassRequest{TicketNumber: res.TicketNumber})
+		assert.NoError(t, err)
+		tokens = append(tokens, pass.Token)
+	}
+	// Ticket 3 is cancelled while the train is offline
+	_, err := server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 3})
+	assert.NoError(t, err)
+
+	scans := []*model.Scan{
+		{TicketNumber: 1, Token: tokens[0], Valid: true, ScannedAt: timestamppb.Now()},
+		{TicketNumber: 1, Token: tokens[0], Valid: true},
+		{TicketNumber: 2, Token: tokens[1], Reason: "ticket has moved to seat 1D; the boarding pass must be reissued"},
+		{TicketNumber: 3, Token: tokens[2], Valid: true},
+		{TicketNumber: 4, Token: tokens[1] + "x", Valid: true},
+	}
+	_, err = server.SyncScans(context.Background(), &model.SyncScansRequest{Scans: scans})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	res, err := server.SyncScans(asStaff("secret"), &model.SyncScansRequest{Scans: scans})
+	assert.NoError(t, err)
+	assert.Len(t, res.Results, 5)
+	assert.True(t, res.Results[0].Boarded)
+	assert.True(t, res.Results[1].Boarded)
+	assert.Equal(t, "refused on board: ticket has moved to seat 1D; the boarding pass must be reissued", res.Results[2].Reason)
+	assert.Equal(t, "ticket is cancelled", res.Results[3].Reason)
+	assert.Equal(t, "invalid boarding pass signature", res.Results[4].Reason)
+
+	boarded := receiptFor(server, 1)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_BOARDED, boarded.Status)
+	assert.Contains(t, boarded.History[len(boarded.History)-1].Detail, "checked in -> boarded, scanned offline at ")
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CHECKED_IN, receiptFor(server, 2).Status)
+}
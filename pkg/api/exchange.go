@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ExchangeTicket implementation
+func (s *TicketServiceServer) ExchangeTicket(ctx context.Context, req *model.ExchangeTicketRequest) (*model.ExchangeTicketResponse, error) {
+	return idempotent(ctx, s.idempotency, "ExchangeTicket", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.ExchangeTicketResponse, error) {
+		return s.exchangeTicket(ctx, req)
+	})
+}
+
+// exchangeTicket replaces a ticket with one on another trip. As with class
+// changes, the new seat is held while the payment is made and the exchange
+// is only committed if the old ticket has not changed and the seat has not
+// been blocked meanwhile, so a failure at any point leaves the passenger with
+// their original seat.
+func (s *TicketServiceServer) exchangeTicket(ctx context.Context, req *model.ExchangeTicketRequest) (*model.ExchangeTicketResponse, error) {
+	target, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	s.ticketsMu.RLock()
+	from := s.ticketTrips[req.TicketNumber]
+	s.ticketsMu.RUnlock()
+	if from == nil {
+		return nil, status.Errorf(codes.NotFound, "ticket not found: %d", req.TicketNumber)
+	}
+	if from == target {
+		return nil, status.Errorf(codes.FailedPrecondition, "ticket %d is already on trip %s; use ModifyUserSeat", req.TicketNumber, target.id)
+	}
+	trips := s.inOrder(from, target)
+
+	unlock := lockTrips(trips, true)
+	ticket := from.tickets[req.TicketNumber]
+	if ticket == nil {
+		unlock()
+		return nil, status.Errorf(codes.NotFound, "ticket not found: %d", req.TicketNumber)
+	}
+	if err := s.requireOwnerOrStaff(ctx, ticket.UserId); err != nil {
+		unlock()
+		return nil, err
+	}
+	if err := checkVersion(ticket, req.ExpectedVersion); err != nil {
+		unlock()
+		return nil, err
+	}
+	if err := checkChangeable(ticket); err != nil {
+		unlock()
+		return nil, err
+	}
+	if s.maxTicketsPerTrip > 0 && ticket.UserId != "" && target.ticketsHeldBy(ticket.UserId) >= s.maxTicketsPerTrip {
+		unlock()
+		return nil, status.Errorf(codes.ResourceExhausted, "passenger %s already holds %d tickets on trip %s", ticket.UserId, s.maxTicketsPerTrip, target.id)
+	}
+	class := req.TravelClass
+	if class == model.TravelClass_TRAVEL_CLASS_UNSPECIFIED {
+		class = ticket.TravelClass
+	}
+	newSeat, err := target.pick(seatChoice{class: class})
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	newFare, err := s.fare(class)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	difference := roundFare(newFare - ticket.PricePaid)
+	due := roundFare(s.changeFee + difference)
+	version := ticket.Version
+	payment := Payment{
+		UserID:    ticket.UserId,
+		Amount:    float32(math.Abs(float64(due))),
+		Reference: fmt.Sprintf("ticket %d exchange at version %d", req.TicketNumber, version),
+	}
+	newSeat.held = req.TicketNumber
+	s.feed.publish(&model.SeatChange{TripId: target.id, Section: newSeat.section, SeatNumber: newSeat.number})
+	unlock()
+
+	paymentID, err := s.settle(ctx, payment, due)
+	if err != nil {
+		s.releaseHold(ctx, target, newSeat)
+		return nil, err
+	}
+
+	unlock = lockTrips(trips, true)
+	ticket = from.tickets[req.TicketNumber]
+	if ticket == nil || ticket.Version != version {
+		unlock()
+		s.releaseHold(ctx, target, newSeat)
+		s.reverse(ctx, payment, due)
+		return nil, status.Errorf(codes.Aborted, "ticket %d changed while its payment was made", req.TicketNumber)
+	}
+	if newSeat.blocked(time.Now()) {
+		unlock()
+		s.releaseHold(ctx, target, newSeat)
+		s.reverse(ctx, payment, due)
+		return nil, status.Errorf(codes.FailedPrecondition, "seat %s was blocked while the payment was made", newSeat.number)
+	}
+	defer unlock()
+
+	number := s.lastTicket.Add(1)
+	exchanged := reissue(ticket, number, target, newSeat)
+	exchanged.PricePaid = newFare
+	actor := s.actor(ctx)
+	recordEvent(exchanged, model.TicketEventType_TICKET_EVENT_TYPE_EXCHANGED, actor,
+		fmt.Sprintf("from ticket %d, trip %s seat %s, change fee %.2f, fare difference %+.2f", ticket.TicketNumber, from.id, seatOrWaitlist(ticket.SeatNumber), s.changeFee, difference))
+	recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_EXCHANGED, actor,
+		fmt.Sprintf("for ticket %d, trip %s seat %s", number, target.id, newSeat.number))
+
+	if changes := from.vacate(ticket); len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	setStatus(ticket, model.TicketStatus_TICKET_STATUS_CANCELLED, actor, "")
+	newSeat.held = 0
+	newSeat.ticket = number
+	ticket.ExchangedTo = number
+	ticket.Version++
+	delete(from.tickets, ticket.TicketNumber)
+	from.receipts[ticket.TicketNumber] = ticket
+	target.tickets[number] = exchanged
+	s.ticketsMu.Lock()
+	s.ticketTrips[number] = target
+	s.ticketsMu.Unlock()
+	s.serveWaitlist(ctx, from)
+
+	if due > 0 {
+		s.metrics.revenue.Add(float64(due))
+	} else if due < 0 {
+		s.metrics.refunds.Add(float64(-due))
+	}
+
+	return &model.ExchangeTicketResponse{
+		Message:        "Ticket exchanged successfully.",
+		Ticket:         s.withUser(exchanged),
+		ChangeFee:      s.changeFee,
+		FareDifference: difference,
+		AmountDue:      due,
+		PaymentId:      paymentID,
+	}, nil
+}
+
+// reissue returns a new ticket, numbered number, for the passenger of
+// ticket in seat st of trip t. The caller sets its price.
+func reissue(ticket *model.Ticket, number int32, t *trip, st *seat) *model.Ticket {
+	reissued := &model.Ticket{
+		From:            ticket.From,
+		To:              ticket.To,
+		SeatNumber:      st.number,
+		Section:         st.section,
+		TicketNumber:    number,
+		UserId:          ticket.UserId,
+		TripId:          t.id,
+		AssistanceNeeds: slices.Clone(ticket.AssistanceNeeds),
+		Version:         1,
+		TravelClass:     t.classes[st.section],
+		Status:          model.TicketStatus_TICKET_STATUS_BOOKED,
+		ExchangedFrom:   ticket.TicketNumber,
+	}
+	if ticket.User != nil {
+		reissued.User = proto.Clone(ticket.User).(*model.User)
+	}
+	return reissued
+}
+
+// lockReceipt finds a live ticket or a receipt and read-locks its trip. It
+// returns a nil ticket, holding no lock, if there is no such ticket;
+// otherwise the caller must call unlock.
+func (s *TicketServiceServer) lockReceipt(number int32) (*model.Ticket, func()) {
+	s.ticketsMu.RLock()
+	t := s.ticketTrips[number]
+	s.ticketsMu.RUnlock()
+	if t == nil {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	ticket, exists := t.tickets[number]
+	if !exists {
+		ticket, exists = t.receipts[number]
+	}
+	if !exists {
+		t.mu.RUnlock()
+		return nil, nil
+	}
+	return ticket, t.mu.RUnlock
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExchangeTicket(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A", "3B"}}))
+	res := purchaseForTest(server)
+
+	exchanged, err := server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{
+		TicketNumber:    res.TicketNumber,
+		TripId:          "T2",
+		ExpectedVersion: res.Version,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(5), exchanged.ChangeFee)
+	assert.Equal(t, float32(0), exchanged.FareDifference)
+	assert.Equal(t, float32(5), exchanged.AmountDue)
+	assert.Equal(t, "charge", exchanged.PaymentId)
+	assert.Equal(t, "T2", exchanged.Ticket.TripId)
+	assert.Equal(t, "3A", exchanged.Ticket.SeatNumber)
+	assert.Equal(t, res.TicketNumber, exchanged.Ticket.ExchangedFrom)
+	assert.Equal(t, "U1", exchanged.Ticket.User.UserId)
+	assert.Equal(t, []Payment{{UserID: "U1", Amount: 5, Reference: "ticket 1 exchange at version 1"}}, payments.charges)
+
+	// The old ticket gives up its seat but keeps its receipt
+	old, err := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	assert.Equal(t, exchanged.Ticket.TicketNumber, old.Ticket.ExchangedTo)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_EXCHANGED, old.Ticket.History[1].Type)
+	assert.Equal(t, 10, server.allTrips()[0].freeSeats("A"))
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSection: "A"})
+	assert.Error(t, err)
+
+	// Exchanging to a first-class seat adds the fare difference to the fee
+	back, err := server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{
+		TicketNumber: exchanged.Ticket.TicketNumber,
+		TravelClass:  model.TravelClass_TRAVEL_CLASS_FIRST,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "2A", back.Ticket.SeatNumber)
+	assert.Equal(t, float32(20), back.AmountDue)
+	assert.Equal(t, float32(35), back.Ticket.PricePaid)
+	assert.Equal(t, 2, server.allTrips()[1].freeSeats("C"))
+}
+
+func TestExchangeTicketNeedsOwnerOrStaff(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments), WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	res := purchaseForTest(server)
+	req := &model.ExchangeTicketRequest{TicketNumber: res.TicketNumber, TripId: "T2"}
+
+	// A stranger can neither move the ticket nor charge the owner for it
+	_, err := server.ExchangeTicket(context.Background(), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.ExchangeTicket(asUser(server, "U2"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Empty(t, payments.charges)
+	assert.Equal(t, 1, server.allTrips()[1].freeSeats("C"))
+
+	exchanged, err := server.ExchangeTicket(asStaff("secret"), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "3A", exchanged.Ticket.SeatNumber)
+}
+
+func TestExchangeTicketToCreatedTrip(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments), WithStaffKey("secret"))
+	_, err := server.CreateTrip(asStaff("secret"), &model.CreateTripRequest{
+		TripId:   "evening",
+		Sections: []*model.TripSection{{Section: "E", TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST, Seats: 4}},
+	})
+	assert.NoError(t, err)
+	res := purchaseForTest(server)
+
+	exchanged, err := server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{
+		TicketNumber: res.TicketNumber,
+		TripId:       "evening",
+		TravelClass:  model.TravelClass_TRAVEL_CLASS_FIRST,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "evening", exchanged.Ticket.TripId)
+	assert.Equal(t, "E1", exchanged.Ticket.SeatNumber)
+	assert.Equal(t, float32(20), exchanged.AmountDue)
+	receipt, err := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: exchanged.Ticket.TicketNumber})
+	assert.NoError(t, err)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_BOOKED, receipt.Ticket.Status)
+}
+
+func TestExchangeTicketReversesPaymentIfTicketChanges(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	res := purchaseForTest(server)
+
+	payments.during = func() {
+		_, err := server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1J"})
+		assert.NoError(t, err)
+	}
+	_, err := server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{TicketNumber: res.TicketNumber, TripId: "T2"})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.Equal(t, []Payment{{UserID: "U1", Amount: 5, Reference: "ticket 1 exchange at version 1 reversal"}}, payments.refunds)
+	assert.Equal(t, 1, server.allTrips()[1].freeSeats("C"))
+}
+
+func TestExchangeTicketSeatBlockedDuringPayment(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments), WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	res := purchaseForTest(server)
+
+	payments.during = func() {
+		_, err := server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{TripId: "T2", Section: "C", Reason: "coach withdrawn"})
+		assert.NoError(t, err)
+	}
+	_, err := server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{TicketNumber: res.TicketNumber, TripId: "T2"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, []Payment{{UserID: "U1", Amount: 5, Reference: "ticket 1 exchange at version 1 reversal"}}, payments.refunds)
+	assert.Equal(t, res.SeatNumber, receiptFor(server, res.TicketNumber).SeatNumber)
+	st := server.allTrips()[1].byNumber["3A"]
+	assert.Zero(t, st.held)
+	assert.Zero(t, st.ticket)
+}
+
+func TestExchangeTicketKeepsOriginalSeatOnFailure(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	res := purchaseForTest(server)
+
+	// Same trip
+	_, err := server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// No seat in the class on the target trip
+	_, err = server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{
+		TicketNumber: res.TicketNumber,
+		TripId:       "T2",
+		TravelClass:  model.TravelClass_TRAVEL_CLASS_FIRST,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Payment declined
+	payments.decline = true
+	_, err = server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{TicketNumber: res.TicketNumber, TripId: "T2"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, 1, server.allTrips()[1].freeSeats("C"))
+
+	// Target full
+	payments.decline = false
+	_, err = server.PurchaseTicket(context.Background(), &model.PurchaseRequest{TripId: "T2", User: &model.User{Email: "other@example.com"}})
+	assert.NoError(t, err)
+	_, err = server.ExchangeTicket(asUser(server, "U1"), &model.ExchangeTicketRequest{TicketNumber: res.TicketNumber, TripId: "T2"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	receipt, err := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	assert.Equal(t, res.SeatNumber, receipt.Ticket.SeatNumber)
+	assert.Equal(t, res.Version, receipt.Ticket.Version)
+	assert.Zero(t, receipt.Ticket.ExchangedTo)
+	assert.Empty(t, payments.charges)
+}
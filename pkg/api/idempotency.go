@@ -0,0 +1,210 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// idempotencyKeyMetadataKey is the request metadata key that may carry
+	// the idempotency key instead of the request field.
+	idempotencyKeyMetadataKey = "idempotency-key"
+
+	defaultIdempotencyTTL = 24 * time.Hour
+
+	// defaultIdempotencyMaxEntries bounds the memory held by stored responses.
+	defaultIdempotencyMaxEntries = 100_000
+
+	// idempotencySweepInterval bounds how often expired responses are purged.
+	idempotencySweepInterval = time.Minute
+)
+
+// idempotencyStore remembers the response to the first call made with each
+// idempotency key so retries can be answered without repeating the change.
+// Once it holds maxEntries keys, the oldest finished ones are forgotten.
+type idempotencyStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+	entries    map[string]*idempotencyEntry
+	order      *list.List // Scoped keys, oldest first
+	lastSweep  time.Time
+}
+
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte
+	done        chan struct{} // Closed once the first call has finished
+	response    proto.Message // Set if the first call succeeded
+	expires     time.Time
+	elem        *list.Element // In order
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	return &idempotencyStore{
+		ttl:        ttl,
+		maxEntries: defaultIdempotencyMaxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*idempotencyEntry),
+		order:      list.New(),
+	}
+}
+
+// idempotencyKey returns the key from the request field, falling back to the
+// request metadata.
+func idempotencyKey(ctx context.Context, field string) string {
+	if field != "" {
+		return field
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(idempotencyKeyMetadataKey); len(keys) > 0 {
+			return keys[0]
+		}
+	}
+	return ""
+}
+
+// idempotencyScope names the caller that owns an idempotency key: its
+// address, narrowed by the user token it sends so users behind one address
+// do not share keys. Only the token's holder can send it again, so it need
+// not be verified here.
+func idempotencyScope(ctx context.Context) string {
+	scope := clientIdentity(ctx)
+	md, _ := metadata.FromIncomingContext(ctx)
+	if tokens := md.Get(userTokenMetadataKey); len(tokens) > 0 && tokens[0] != "" {
+		scope += "/user:" + tokens[0]
+	}
+	return scope
+}
+
+// requestFingerprint hashes req without its idempotency key, so retries of
+// the same request match whichever way the key was sent.
+func requestFingerprint(req proto.Message) [sha256.Size]byte {
+	req = proto.Clone(req)
+	m := req.ProtoReflect()
+	if fd := m.Descriptor().Fields().ByName("idempotency_key"); fd != nil {
+		m.Clear(fd)
+	}
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	return sha256.Sum256(data)
+}
+
+// idempotent runs fn once per caller, method and key. A repeat of a
+// successful call gets a copy of the stored response; a repeat arriving while
+// the first call is running waits for it; a key reused with a different
+// request is rejected. Failed calls are not stored, so they can be retried.
+// Calls without a key always run. Keys are scoped to the caller as named by
+// idempotencyScope, so one client can neither read nor block another's
+// responses by guessing its keys.
+func idempotent[Res proto.Message](ctx context.Context, st *idempotencyStore, method, key string, req proto.Message, fn func() (Res, error)) (Res, error) {
+	if key == "" {
+		return fn()
+	}
+	scoped := idempotencyScope(ctx) + "\x00" + method + "\x00" + key
+	fingerprint := requestFingerprint(req)
+
+	for {
+		st.mu.Lock()
+		now := st.now()
+		st.sweep(now)
+		entry, exists := st.entries[scoped]
+		if exists && entry.response != nil && !now.Before(entry.expires) {
+			st.remove(scoped, entry)
+			exists = false
+		}
+		if !exists {
+			break
+		}
+		st.mu.Unlock()
+
+		if entry.fingerprint != fingerprint {
+			var zero Res
+			return zero, status.Error(codes.InvalidArgument, "idempotency key was already used with a different request")
+		}
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			var zero Res
+			return zero, status.FromContextError(ctx.Err()).Err()
+		}
+		if entry.response != nil {
+			return proto.Clone(entry.response).(Res), nil
+		}
+		// The first call failed and was forgotten; try to run it here.
+	}
+
+	if !st.makeRoom() {
+		st.mu.Unlock()
+		var zero Res
+		return zero, status.Error(codes.ResourceExhausted, "too many calls with idempotency keys in progress")
+	}
+	entry := &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	st.entries[scoped] = entry
+	entry.elem = st.order.PushBack(scoped)
+	st.mu.Unlock()
+
+	// A panicking fn is treated like a failed one: the entry is forgotten
+	// and callers waiting on it are released before the panic goes on.
+	succeeded := false
+	defer func() {
+		st.mu.Lock()
+		if !succeeded {
+			st.remove(scoped, entry)
+		}
+		close(entry.done)
+		st.mu.Unlock()
+	}()
+
+	res, err := fn()
+	if err == nil {
+		st.mu.Lock()
+		entry.response = proto.Clone(res)
+		entry.expires = st.now().Add(st.ttl)
+		st.mu.Unlock()
+		succeeded = true
+	}
+	return res, err
+}
+
+// sweep drops expired responses, at most once per idempotencySweepInterval.
+// Callers must hold st.mu.
+func (st *idempotencyStore) sweep(now time.Time) {
+	if now.Sub(st.lastSweep) < idempotencySweepInterval {
+		return
+	}
+	st.lastSweep = now
+	for key, entry := range st.entries {
+		if entry.response != nil && !now.Before(entry.expires) {
+			st.remove(key, entry)
+		}
+	}
+}
+
+// makeRoom forgets the oldest stored responses until there is room for one
+// more entry. It returns false if every entry is a call still running.
+// Callers must hold st.mu.
+func (st *idempotencyStore) makeRoom() bool {
+	for e := st.order.Front(); e != nil && len(st.entries) >= st.maxEntries; {
+		next := e.Next()
+		key := e.Value.(string)
+		if entry := st.entries[key]; entry.response != nil {
+			st.remove(key, entry)
+		}
+		e = next
+	}
+	return len(st.entries) < st.maxEntries
+}
+
+// remove forgets entry, stored under key. Callers must hold st.mu.
+func (st *idempotencyStore) remove(key string, entry *idempotencyEntry) {
+	delete(st.entries, key)
+	st.order.Remove(entry.elem)
+}
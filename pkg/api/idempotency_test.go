@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestPurchaseTicketIdempotencyKey(t *testing.T) {
+	server := NewTicketServiceServer()
+	req := &model.PurchaseRequest{
+		From:           "City A",
+		To:             "City B",
+		User:           &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+		IdempotencyKey: "key-1",
+	}
+
+	first, err := server.PurchaseTicket(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The same key sent as metadata replays the stored response.
+	retry := &model.PurchaseRequest{From: req.From, To: req.To, User: req.User}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyMetadataKey, "key-1"))
+	second, err := server.PurchaseTicket(ctx, retry)
+	assert.NoError(t, err)
+	assert.Equal(t, first.TicketNumber, second.TicketNumber)
+	assert.Equal(t, first.SeatNumber, second.SeatNumber)
+
+	mapRes, _ := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{})
+	assert.Equal(t, model.SeatStatus_SEAT_STATUS_FREE, mapRes.Seats[1].Status)
+
+	other := &model.PurchaseRequest{From: "City A", To: "City C", User: req.User, IdempotencyKey: "key-1"}
+	_, err = server.PurchaseTicket(context.Background(), other)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Keys are scoped to the method they were used with.
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: first.TicketNumber, IdempotencyKey: "key-1"})
+	assert.NoError(t, err)
+}
+
+func TestIdempotencyScopedToCaller(t *testing.T) {
+	server := NewTicketServiceServer()
+	req := &model.PurchaseRequest{
+		From:           "City A",
+		To:             "City B",
+		User:           &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+		IdempotencyKey: "key-1",
+	}
+	first, err := server.PurchaseTicket(asUser(server, "U1"), req)
+	assert.NoError(t, err)
+
+	// Another caller reusing the key gets neither the stored response nor
+	// a conflict; its call runs on its own
+	req.User = &model.User{FirstName: "Bob", LastName: "Doe", Email: "bob@example.com"}
+	second, err := server.PurchaseTicket(asUser(server, "U2"), req)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.TicketNumber, second.TicketNumber)
+}
+
+func TestIdempotencyMaxEntries(t *testing.T) {
+	server := NewTicketServiceServer(WithIdempotencyMaxEntries(2))
+	purchaseForTest(server)
+	modify := func(key, section string) *model.ModifySeatResponse {
+		res, err := server.ModifyUserSeat(ownerOf(server, 1), &model.ModifySeatRequest{TicketNumber: 1, NewSection: section, IdempotencyKey: key})
+		assert.NoError(t, err)
+		return res
+	}
+	modify("key-1", "B")
+	modify("key-2", "A")
+	modify("key-3", "B")
+	assert.Len(t, server.idempotency.entries, 2)
+
+	// The oldest key was forgotten, so reusing it runs the call again
+	_, err := server.ModifyUserSeat(ownerOf(server, 1), &model.ModifySeatRequest{TicketNumber: 1, NewSection: "A", IdempotencyKey: "key-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "A", receiptFor(server, 1).Section)
+}
+
+func TestIdempotencyConcurrentDuplicates(t *testing.T) {
+	server := NewTicketServiceServer()
+	req := &model.PurchaseRequest{
+		From:           "City A",
+		To:             "City B",
+		User:           &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+		IdempotencyKey: "key-1",
+	}
+
+	var wg sync.WaitGroup
+	numbers := make([]int32, 10)
+	for i := range numbers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := server.PurchaseTicket(context.Background(), req)
+			assert.NoError(t, err)
+			numbers[i] = res.TicketNumber
+		}()
+	}
+	wg.Wait()
+
+	for _, n := range numbers {
+		assert.Equal(t, int32(1), n)
+	}
+	listRes, _ := server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{})
+	assert.Len(t, listRes.Tickets, 1)
+}
+
+func TestIdempotencyExpiryAndFailures(t *testing.T) {
+	server := NewTicketServiceServer(WithIdempotencyTTL(time.Hour))
+	now := time.Now()
+	server.idempotency.now = func() time.Time { return now }
+
+	// A failed call is not remembered, so its retry runs again.
+	req := &model.ModifySeatRequest{TicketNumber: 1, NewSection: "B", IdempotencyKey: "key-1"}
+	owner := asUser(server, "U1")
+	_, err := server.ModifyUserSeat(owner, req)
+	assert.Error(t, err)
+	purchaseForTest(server)
+	_, err = server.ModifyUserSeat(owner, req)
+	assert.NoError(t, err)
+
+	remove := &model.RemoveUserRequest{TicketNumber: 1, IdempotencyKey: "key-2"}
+	res, _ := server.RemoveUser(context.Background(), remove)
+	assert.Equal(t, "User removed successfully.", res.Message)
+	res, _ = server.RemoveUser(context.Background(), remove)
+	assert.Equal(t, "User removed successfully.", res.Message)
+
+	now = now.Add(2 * time.Hour)
+	res, _ = server.RemoveUser(context.Background(), remove)
+	assert.Equal(t, "User not found.", res.Message)
+}
+
+func TestIdempotencyPanicReleasesKey(t *testing.T) {
+	server := NewTicketServiceServer()
+	st := server.idempotency
+	req := &model.RemoveUserRequest{TicketNumber: 1, IdempotencyKey: "key-1"}
+
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		idempotent(context.Background(), st, "RemoveUser", "key-1", req, func() (*model.RemoveUserResponse, error) {
+			close(started)
+			<-proceed
+			panic("boom")
+		})
+	}()
+	<-started
+
+	// A duplicate waiting on the panicking call runs fn itself afterwards.
+	done := make(chan *model.RemoveUserResponse)
+	go func() {
+		res, _ := idempotent(context.Background(), st, "RemoveUser", "key-1", req, func() (*model.RemoveUserResponse, error) {
+			return &model.RemoveUserResponse{Message: "retried"}, nil
+		})
+		done <- res
+	}()
+	close(proceed)
+
+	select {
+	case res := <-done:
+		assert.Equal(t, "retried", res.Message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("duplicate call still waiting after the first call panicked")
+	}
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultTripID is the trip booked when a request names no trip.
+	defaultTripID = "default"
+
+	// seatsPerRow is the width of a section: two seats either side of the aisle.
+	seatsPerRow = 4
+)
+
+// seat is one physical seat on a trip.
+type seat struct {
+	number     string
+	section    string
+	index      int // Position in the trip's allocation order
+	row        int32
+	column     int32
+	attributes []string
+	ticket     int32        // Ticket number occupying the seat, 0 when free
+	held       int32        // Ticket moving here once its payment clears, 0 when none
+	blocks     []*seatBlock // Blocks covering the seat, including future ones
+}
+
+// trip is the seat inventory of one train journey and the tickets sold for
+// it. Its lock guards the seats' occupancy and blocks and the tickets; the
+// layout and departure never change once the trip is added.
+type trip struct {
+	mu       sync.RWMutex
+	id       string
+	departs  time.Time                    // Zero if not scheduled
+	sections []string                     // Sections in allocation order
+	classes  map[string]model.TravelClass // Travel class of each section
+	seats    []*seat                      // Seats in allocation order
+	byNumber map[string]*seat             // Seats keyed by seat number
+	tickets  map[int32]*model.Ticket      // Live tickets keyed by ticket number
+	// Tickets that are no longer live, because they were exchanged,
+	// cancelled or refunded, kept only as receipts
+	receipts  map[int32]*model.Ticket
+	blocks    []*seatBlock // Seat blocks in the order they were placed
+	lastBlock int          // Number of the last block placed
+	waitlist  []int32      // Tickets without a seat, in the order they wait
+	// Overbooking percentage of sections set by staff
+	overbooking map[string]int32
+	// Client each ticket was bought by, for the per client ticket cap
+	buyers map[int32]string
+}
+
+// newTrip lays out the seats of each section in rows of seatsPerRow, in the
+// order given. Every section is standard class until set otherwise.
+func newTrip(id string, sections []string, seatNumbers map[string][]string) *trip {
+	t := &trip{
+		id:       id,
+		sections: sections,
+		classes:  make(map[string]model.TravelClass),
+		byNumber: make(map[string]*seat),
+		tickets:  make(map[int32]*model.Ticket),
+		receipts: make(map[int32]*model.Ticket),
+
+		overbooking: make(map[string]int32),
+		buyers:      make(map[int32]string),
+	}
+	for _, section := range sections {
+		t.classes[section] = model.TravelClass_TRAVEL_CLASS_STANDARD
+		for i, number := range seatNumbers[section] {
+			column := int32(i%seatsPerRow) + 1
+			st := &seat{
+				number:  number,
+				section: section,
+				index:   len(t.seats),
+				row:     int32(i/seatsPerRow) + 1,
+				column:  column,
+			}
+			if column == 1 || column == seatsPerRow {
+				st.attributes = []string{"window"}
+			} else {
+				st.attributes = []string{"aisle"}
+			}
+			t.seats = append(t.seats, st)
+			t.byNumber[number] = st
+		}
+	}
+	return t
+}
+
+// newDefaultTrip returns the two-section, twenty-seat train the service has
+// always sold: standard class in section A and first class in section B.
+func newDefaultTrip() *trip {
+	t := newTrip(defaultTripID, []string{"A", "B"}, map[string][]string{
+		"A": {"1A", "1B", "1C", "1D", "1E", "1F", "1G", "1H", "1I", "1J"},
+		"B": {"2A", "2B", "2C", "2D", "2E", "2F", "2G", "2H", "2I", "2J"},
+	})
+	t.classes["B"] = model.TravelClass_TRAVEL_CLASS_FIRST
+	return t
+}
+
+// free reports whether the seat can be sold.
+func (st *seat) free() bool {
+	return st.freeAt(time.Now())
+}
+
+// freeAt reports whether the seat, as occupied now, can be sold at time at.
+func (st *seat) freeAt(at time.Time) bool {
+	return st.ticket == 0 && st.held == 0 && !st.blocked(at)
+}
+
+// freeSeat returns the first free seat in section, or nil if it is full.
+// Callers must hold t.mu.
+func (t *trip) freeSeat(section string) *seat {
+	for _, st := range t.seats {
+		if st.section == section && st.free() {
+			return st
+		}
+	}
+	return nil
+}
+
+// freeSeats counts the free seats in section. Callers must hold t.mu.
+func (t *trip) freeSeats(section string) int {
+	n := 0
+	for _, st := range t.seats {
+		if st.section == section && st.free() {
+			n++
+		}
+	}
+	return n
+}
+
+// seatChoice says where a ticket should go: a particular seat, else the first
+// free seat of a section, else the first free seat of a travel class.
+type seatChoice struct {
+	seat    string
+	section string
+	class   model.TravelClass
+}
+
+// pick returns the free seat c names. Callers must hold t.mu.
+func (t *trip) pick(c seatChoice) (*seat, error) {
+	switch {
+	case c.seat != "":
+		st := t.byNumber[c.seat]
+		if st == nil || (c.section != "" && st.section != c.section) {
+			return nil, status.Errorf(codes.NotFound, "seat %s not found in section %s", c.seat, c.section)
+		}
+		if !st.free() {
+			return nil, status.Errorf(codes.FailedPrecondition, "seat %s is not available", c.seat)
+		}
+		return st, nil
+	case c.section != "" || c.class == model.TravelClass_TRAVEL_CLASS_UNSPECIFIED:
+		if st := t.freeSeat(c.section); st != nil {
+			return st, nil
+		}
+		return nil, fmt.Errorf("no available seats in section %s", c.section)
+	default:
+		for _, section := range t.sections {
+			if t.classes[section] != c.class {
+				continue
+			}
+			if st := t.freeSeat(section); st != nil {
+				return st, nil
+			}
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "no available seats in %s class", className(c.class))
+	}
+}
+
+// ticketsHeldBy counts the tickets userID holds on t. Callers must hold t.mu.
+func (t *trip) ticketsHeldBy(userID string) int {
+	n := 0
+	for _, ticket := range t.tickets {
+		if ticket.UserId == userID {
+			n++
+		}
+	}
+	return n
+}
+
+// ticketsBoughtBy counts the live tickets on t bought by client buyer.
+// Callers must hold t.mu.
+func (t *trip) ticketsBoughtBy(buyer string) int {
+	n := 0
+	for number := range t.tickets {
+		if t.buyers[number] == buyer {
+			n++
+		}
+	}
+	return n
+}
+
+func (st *seat) status() model.SeatStatus {
+	switch {
+	case st.blocked(time.Now()):
+		return model.SeatStatus_SEAT_STATUS_BLOCKED
+	case st.ticket != 0:
+		return model.SeatStatus_SEAT_STATUS_SOLD
+	case st.held != 0:
+		return model.SeatStatus_SEAT_STATUS_HELD
+	}
+	return model.SeatStatus_SEAT_STATUS_FREE
+}
+
+// addTrip registers a trip for sale, failing if its ID is taken.
+func (s *TicketServiceServer) addTrip(t *trip) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.trips[t.id]; exists {
+		return status.Errorf(codes.AlreadyExists, "trip already exists: %s", t.id)
+	}
+	s.trips[t.id] = t
+	s.tripOrder = append(s.tripOrder, t.id)
+	return nil
+}
+
+// trip looks up a trip by ID, with an empty ID meaning the default trip.
+// Trips are never removed, so the result stays valid after s.mu is released.
+func (s *TicketServiceServer) trip(id string) (*trip, error) {
+	if id == "" {
+		id = defaultTripID
+	}
+	s.mu.RLock()
+	t, exists := s.trips[id]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "trip not found: %s", id)
+	}
+	return t, nil
+}
+
+// allTrips returns every trip in the order they were added, which is the
+// order their locks must be taken in.
+func (s *TicketServiceServer) allTrips() []*trip {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trips := make([]*trip, 0, len(s.tripOrder))
+	for _, id := range s.tripOrder {
+		trips = append(trips, s.trips[id])
+	}
+	return trips
+}
+
+// inOrder returns the given trips in the order their locks must be taken in.
+func (s *TicketServiceServer) inOrder(trips ...*trip) []*trip {
+	var ordered []*trip
+	for _, t := range s.allTrips() {
+		if slices.Contains(trips, t) {
+			ordered = append(ordered, t)
+		}
+	}
+	return ordered
+}
+
+// lockTrips locks trips, which must be in the order they were added, for
+// writing if write is set and for reading otherwise. It returns the function
+// that unlocks them.
+func lockTrips(trips []*trip, write bool) (unlock func()) {
+	for _, t := range trips {
+		if write {
+			t.mu.Lock()
+		} else {
+			t.mu.RLock()
+		}
+	}
+	return func() {
+		for i := len(trips) - 1; i >= 0; i-- {
+			if write {
+				trips[i].mu.Unlock()
+			} else {
+				trips[i].mu.RUnlock()
+			}
+		}
+	}
+}
+
+// GetSeatMap implementation
+func (s *TicketServiceServer) GetSeatMap(ctx context.Context, req *model.GetSeatMapRequest) (*model.GetSeatMapResponse, error) {
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	seats := make([]*model.Seat, 0, len(t.seats))
+	for _, st := range t.seats {
+		seats = append(seats, &model.Seat{
+			SeatNumber:  st.number,
+			Section:     st.section,
+			Status:      st.status(),
+			Attributes:  append([]string(nil), st.attributes...),
+			Row:         st.row,
+			Column:      st.column,
+			TravelClass: t.classes[st.section],
+		})
+	}
+
+	return &model.GetSeatMapResponse{
+		TripId: t.id,
+		Seats:  seats,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetSeatMap(t *testing.T) {
+	server := NewTicketServiceServer()
+	res := purchaseForTest(server)
+
+	mapRes, err := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultTripID, mapRes.TripId)
+	assert.Len(t, mapRes.Seats, 20)
+
+	first := mapRes.Seats[0]
+	assert.Equal(t, res.SeatNumber, first.SeatNumber)
+	assert.Equal(t, model.SeatStatus_SEAT_STATUS_SOLD, first.Status)
+	assert.Equal(t, []string{"window"}, first.Attributes)
+	assert.Equal(t, int32(1), first.Row)
+	assert.Equal(t, int32(1), first.Column)
+
+	// The fifth seat of a section starts its second row.
+	fifth := mapRes.Seats[4]
+	assert.Equal(t, "1E", fifth.SeatNumber)
+	assert.Equal(t, model.SeatStatus_SEAT_STATUS_FREE, fifth.Status)
+	assert.Equal(t, int32(2), fifth.Row)
+	assert.Equal(t, int32(1), fifth.Column)
+	assert.Equal(t, []string{"aisle"}, mapRes.Seats[1].Attributes)
+
+	_, err = server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{TripId: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestPurchaseOnTrip(t *testing.T) {
+	server := NewTicketServiceServer()
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+
+	res, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		From:   "City A",
+		To:     "City B",
+		User:   &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+		TripId: "T2",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "T2", res.TripId)
+	assert.Equal(t, "3A", res.SeatNumber)
+
+	_, err = server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		User:   &model.User{FirstName: "Bob", LastName: "Doe", Email: "bob@example.com"},
+		TripId: "T2",
+	})
+	assert.Error(t, err)
+
+	mapRes, _ := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{})
+	for _, seat := range mapRes.Seats {
+		assert.Equal(t, model.SeatStatus_SEAT_STATUS_FREE, seat.Status)
+	}
+}
+
+func TestModifyUserSeatToRequestedSeat(t *testing.T) {
+	server := NewTicketServiceServer()
+	first := purchaseForTest(server)
+	second := purchaseForTest(server)
+
+	_, err := server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{
+		TicketNumber:  first.TicketNumber,
+		NewSeatNumber: second.SeatNumber,
+		NewSection:    "A",
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.ModifyUserSeat(ownerOf(server, first.TicketNumber), &model.ModifySeatRequest{
+		TicketNumber:  first.TicketNumber,
+		NewSeatNumber: "2E",
+		NewSection:    "B",
+	})
+	assert.NoError(t, err)
+
+	receiptRes, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: first.TicketNumber})
+	assert.Equal(t, "2E", receiptRes.Ticket.SeatNumber)
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ticketTransitions lists the statuses a ticket may move to from each
+// status. Statuses not listed are final.
+var ticketTransitions = map[model.TicketStatus][]model.TicketStatus{
+	model.TicketStatus_TICKET_STATUS_BOOKED: {
+		model.TicketStatus_TICKET_STATUS_CHECKED_IN,
+		model.TicketStatus_TICKET_STATUS_NO_SHOW,
+		model.TicketStatus_TICKET_STATUS_CANCELLED,
+		model.TicketStatus_TICKET_STATUS_REFUNDED,
+	},
+	model.TicketStatus_TICKET_STATUS_CHECKED_IN: {
+		model.TicketStatus_TICKET_STATUS_BOARDED,
+		model.TicketStatus_TICKET_STATUS_NO_SHOW,
+		model.TicketStatus_TICKET_STATUS_CANCELLED,
+	},
+}
+
+// statusName returns the lower-case name of a ticket status, such as
+// "checked in".
+func statusName(st model.TicketStatus) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(st.String(), "TICKET_STATUS_"), "_", " "))
+}
+
+// checkTransition fails with FailedPrecondition unless ticket may move to
+// status to.
+func checkTransition(ticket *model.Ticket, to model.TicketStatus) error {
+	if !slices.Contains(ticketTransitions[ticket.Status], to) {
+		return status.Errorf(codes.FailedPrecondition, "ticket %d is %s and cannot become %s", ticket.TicketNumber, statusName(ticket.Status), statusName(to))
+	}
+	return nil
+}
+
+// checkChangeable fails with FailedPrecondition unless the seat, class or
+// trip of ticket may still be changed: only booked and checked-in tickets
+// can be.
+func checkChangeable(ticket *model.Ticket) error {
+	switch ticket.Status {
+	case model.TicketStatus_TICKET_STATUS_BOOKED, model.TicketStatus_TICKET_STATUS_CHECKED_IN:
+		return nil
+	}
+	return status.Errorf(codes.FailedPrecondition, "ticket %d is %s", ticket.TicketNumber, statusName(ticket.Status))
+}
+
+// setStatus moves ticket to status to and records the change, with note
+// appended if it is not empty. Callers check the transition first and bump
+// the version.
+func setStatus(ticket *model.Ticket, to model.TicketStatus, actor, note string) {
+	detail := statusName(ticket.Status) + " -> " + statusName(to)
+	if note != "" {
+		detail += ", " + note
+	}
+	recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_STATUS_CHANGED, actor, detail)
+	ticket.Status = to
+}
+
+// CheckIn implementation
+func (s *TicketServiceServer) CheckIn(ctx context.Context, req *model.CheckInRequest) (*model.CheckInResponse, error) {
+	return idempotent(ctx, s.idempotency, "CheckIn", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.CheckInResponse, error) {
+		return s.checkIn(ctx, req)
+	})
+}
+
+// checkIn checks a booked ticket in. Overbooked tickets are given a seat
+// here, and cannot check in until one is free. Waitlisted tickets wait their
+// turn, so they cannot take a seat ahead of those before them.
+func (s *TicketServiceServer) checkIn(ctx context.Context, req *model.CheckInRequest) (*model.CheckInResponse, error) {
+	t, ticket, unlock := s.lockTicket(req.TicketNumber, true)
+	if ticket == nil {
+		return nil, status.Errorf(codes.NotFound, "ticket not found: %d", req.TicketNumber)
+	}
+	defer unlock()
+	if err := s.requireOwnerOrStaff(ctx, ticket.UserId); err != nil {
+		return nil, err
+	}
+	if err := checkVersion(ticket, req.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	if err := checkTransition(ticket, model.TicketStatus_TICKET_STATUS_CHECKED_IN); err != nil {
+		return nil, err
+	}
+
+	actor := s.actor(ctx)
+	if ticket.SeatNumber == "" {
+		if slices.Contains(t.waitlist, ticket.TicketNumber) {
+			return nil, status.Errorf(codes.FailedPrecondition, "ticket %d is on the waitlist; you will be told when it has a seat", req.TicketNumber)
+		}
+		st := t.seatFor(ticket)
+		if st == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "no seat is free for ticket %d; ask staff at the station", req.TicketNumber)
+		}
+		s.feed.publish(t.assignSeat(ticket, st, actor))
+	}
+	setStatus(ticket, model.TicketStatus_TICKET_STATUS_CHECKED_IN, actor, "")
+	ticket.Version++
+
+	return &model.CheckInResponse{
+		Message: fmt.Sprintf("Checked in. Your seat is %s.", ticket.SeatNumber),
+		Ticket:  s.withUser(ticket),
+	}, nil
+}
+
+// Board implementation
+func (s *TicketServiceServer) Board(ctx context.Context, req *model.BoardRequest) (*model.BoardResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return idempotent(ctx, s.idempotency, "Board", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.BoardResponse, error) {
+		_, ticket, unlock := s.lockTicket(req.TicketNumber, true)
+		if ticket == nil {
+			return nil, status.Errorf(codes.NotFound, "ticket not found: %d", req.TicketNumber)
+		}
+		defer unlock()
+		if err := checkTransition(ticket, model.TicketStatus_TICKET_STATUS_BOARDED); err != nil {
+			return nil, err
+		}
+		setStatus(ticket, model.TicketStatus_TICKET_STATUS_BOARDED, s.actor(ctx), "")
+		ticket.Version++
+
+		return &model.BoardResponse{Message: "Passenger boarded.", Ticket: s.withUser(ticket)}, nil
+	})
+}
+
+// ProcessNoShows implementation
+func (s *TicketServiceServer) ProcessNoShows(ctx context.Context, req *model.ProcessNoShowsRequest) (*model.ProcessNoShowsResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return idempotent(ctx, s.idempotency, "ProcessNoShows", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.ProcessNoShowsResponse, error) {
+		return s.processNoShows(ctx, req)
+	})
+}
+
+// processNoShows marks the tickets of a departed trip that never boarded as
+// no-shows. Their seats are freed if req.ReleaseSeats is set; tickets
+// without a seat leave the waitlist either way.
+func (s *TicketServiceServer) processNoShows(ctx context.Context, req *model.ProcessNoShowsRequest) (*model.ProcessNoShowsResponse, error) {
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	// Passengers may still check in and board until the train leaves
+	if t.departs.IsZero() {
+		return nil, status.Errorf(codes.FailedPrecondition, "trip %s has no departure time", t.id)
+	}
+	if time.Now().Before(t.departs) {
+		return nil, status.Errorf(codes.FailedPrecondition, "trip %s has not departed yet; it leaves at %s", t.id, t.departs.UTC().Format(time.RFC3339))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var tickets []*model.Ticket
+	for _, ticket := range t.tickets {
+		if checkTransition(ticket, model.TicketStatus_TICKET_STATUS_NO_SHOW) == nil {
+			tickets = append(tickets, ticket)
+		}
+	}
+	slices.SortFunc(tickets, func(a, b *model.Ticket) int { return int(a.TicketNumber - b.TicketNumber) })
+
+	res := &model.ProcessNoShowsResponse{}
+	var changes []*model.SeatChange
+	actor := s.actor(ctx)
+	for _, ticket := range tickets {
+		note := ""
+		if ticket.SeatNumber == "" || req.ReleaseSeats {
+			changes = append(changes, t.vacate(ticket)...)
+		}
+		if ticket.SeatNumber != "" && req.ReleaseSeats {
+			note = "seat " + ticket.SeatNumber + " released"
+			ticket.SeatNumber = ""
+			res.SeatsReleased++
+		}
+		setStatus(ticket, model.TicketStatus_TICKET_STATUS_NO_SHOW, actor, note)
+		ticket.Version++
+		res.TicketNumbers = append(res.TicketNumbers, ticket.TicketNumber)
+	}
+	if len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+
+	return res, nil
+}
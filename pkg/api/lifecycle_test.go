@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCheckInAndBoard(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	res := purchaseForTest(server)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_BOOKED, receiptFor(server, res.TicketNumber).Status)
+
+	// Boarding needs a staff key and a checked-in ticket
+	_, err := server.Board(context.Background(), &model.BoardRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.Board(asStaff("secret"), &model.BoardRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.ErrorContains(t, err, "ticket 1 is booked and cannot become boarded")
+
+	// Only the owner or staff may check a ticket in
+	_, err = server.CheckIn(context.Background(), &model.CheckInRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.CheckIn(asUser(server, "U2"), &model.CheckInRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.CheckIn(ownerOf(server, res.TicketNumber), &model.CheckInRequest{TicketNumber: res.TicketNumber, ExpectedVersion: res.Version + 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	checkedIn, err := server.CheckIn(ownerOf(server, res.TicketNumber), &model.CheckInRequest{TicketNumber: res.TicketNumber, ExpectedVersion: res.Version})
+	assert.NoError(t, err)
+	assert.Equal(t, "Checked in. Your seat is 1A.", checkedIn.Message)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CHECKED_IN, checkedIn.Ticket.Status)
+	_, err = server.CheckIn(ownerOf(server, res.TicketNumber), &model.CheckInRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// A checked-in passenger may still change seats, but not once boarded
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1B"})
+	assert.NoError(t, err)
+	boarded, err := server.Board(asStaff("secret"), &model.BoardRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_BOARDED, boarded.Ticket.Status)
+	assert.Equal(t, "checked in -> boarded", boarded.Ticket.History[len(boarded.Ticket.History)-1].Detail)
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1C"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestCheckInAssignsOverbookedSeat(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"1A"}}))
+	_, err := server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "T2", Percent: 100})
+	assert.NoError(t, err)
+	purchaseOnTrip(server, "T2", "a")
+	overbooked, _ := purchaseOnTrip(server, "T2", "b")
+	assert.True(t, overbooked.Overbooked)
+
+	_, err = server.CheckIn(ownerOf(server, overbooked.TicketNumber), &model.CheckInRequest{TicketNumber: overbooked.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// The first passenger cancels; their ticket is kept as a receipt
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CANCELLED, receiptFor(server, 1).Status)
+
+	checkedIn, err := server.CheckIn(ownerOf(server, overbooked.TicketNumber), &model.CheckInRequest{TicketNumber: overbooked.TicketNumber})
+	assert.NoError(t, err)
+	assert.Equal(t, "1A", checkedIn.Ticket.SeatNumber)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_ASSIGNED, checkedIn.Ticket.History[1].Type)
+}
+
+func TestCheckInLeavesWaitlistedTicketsWaiting(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C", "D"}, map[string][]string{"C": {"3A"}, "D": {"4A"}}))
+	purchaseOnTrip(server, "T2", "a")
+	purchaseOnTrip(server, "T2", "b")
+	_, err := server.RelocateSection(asStaff("secret"), &model.RelocateSectionRequest{TripId: "T2", Section: "D", Reason: "fire damage"})
+	assert.NoError(t, err)
+
+	// The waitlist, not check-in, decides who gets the next free seat
+	_, err = server.CheckIn(ownerOf(server, 2), &model.CheckInRequest{TicketNumber: 2})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.ErrorContains(t, err, "ticket 2 is on the waitlist")
+	assert.Empty(t, receiptFor(server, 2).SeatNumber)
+}
+
+func TestProcessNoShows(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"), WithDefaultTripDeparture(time.Now().Add(-time.Minute)))
+	booked := purchaseForTest(server)
+	checkedIn := purchaseForTest(server)
+	boarded := purchaseForTest(server)
+	for _, n := range []int32{checkedIn.TicketNumber, boarded.TicketNumber} {
+		_, err := server.CheckIn(ownerOf(server, n), &model.CheckInRequest{TicketNumber: n})
+		assert.NoError(t, err)
+	}
+	_, err := server.Board(asStaff("secret"), &model.BoardRequest{TicketNumber: boarded.TicketNumber})
+	assert.NoError(t, err)
+
+	_, err = server.ProcessNoShows(context.Background(), &model.ProcessNoShowsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	res, err := server.ProcessNoShows(asStaff("secret"), &model.ProcessNoShowsRequest{ReleaseSeats: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{booked.TicketNumber, checkedIn.TicketNumber}, res.TicketNumbers)
+	assert.Equal(t, int32(2), res.SeatsReleased)
+
+	noShow := receiptFor(server, booked.TicketNumber)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_NO_SHOW, noShow.Status)
+	assert.Empty(t, noShow.SeatNumber)
+	assert.Equal(t, "booked -> no show, seat 1A released", noShow.History[len(noShow.History)-1].Detail)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_BOARDED, receiptFor(server, boarded.TicketNumber).Status)
+	assert.Equal(t, 9, server.allTrips()[0].freeSeats("A"))
+
+	// A released seat can be sold again; no-shows cannot check in
+	resold := purchaseForTest(server)
+	assert.Equal(t, "1A", resold.SeatNumber)
+	_, err = server.CheckIn(ownerOf(server, booked.TicketNumber), &model.CheckInRequest{TicketNumber: booked.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestProcessNoShowsBeforeDeparture(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	_, err := server.ProcessNoShows(asStaff("secret"), &model.ProcessNoShowsRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.ErrorContains(t, err, "trip default has no departure time")
+
+	_, err = server.CreateTrip(asStaff("secret"), &model.CreateTripRequest{
+		TripId:    "T2",
+		Sections:  []*model.TripSection{{Section: "C", Seats: 1}},
+		DepartsAt: timestamppb.New(time.Now().Add(time.Hour)),
+	})
+	assert.NoError(t, err)
+	booked, _ := purchaseOnTrip(server, "T2", "a")
+	_, err = server.ProcessNoShows(asStaff("secret"), &model.ProcessNoShowsRequest{TripId: "T2"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.ErrorContains(t, err, "trip T2 has not departed yet")
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_BOOKED, receiptFor(server, booked.TicketNumber).Status)
+}
+
+func receiptFor(server *TicketServiceServer, ticketNumber int32) *model.Ticket {
+	res, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: ticketNumber})
+	return res.GetTicket()
+}
+
+// ownerOf returns a context carrying the user ID of the ticket's owner.
+func ownerOf(server *TicketServiceServer, ticketNumber int32) context.Context {
+	return asUser(server, receiptFor(server, ticketNumber).UserId)
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// pageCursor marks the last ticket of a page. Pages continue from the first
+// ticket that sorts after it, so tickets added or removed elsewhere in the
+// listing never shift or repeat results.
+type pageCursor struct {
+	Query  string `json:"q"` // Fingerprint of the filters and order the cursor belongs to
+	Key    string `json:"k"`
+	Ticket int32  `json:"t"`
+}
+
+// listedTicket is a ticket with the key it sorts by.
+type listedTicket struct {
+	ticket *model.Ticket
+	key    string
+}
+
+func (l listedTicket) after(c *pageCursor) bool {
+	if l.key != c.Key {
+		return l.key > c.Key
+	}
+	return l.ticket.TicketNumber > c.Ticket
+}
+
+// queryFingerprint identifies the filters and order of a listing request.
+func queryFingerprint(req *model.ViewUsersBySectionRequest) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00%s",
+		req.Section, req.SortBy, req.TripId, req.From, req.To, strings.ToLower(req.Email), strings.ToLower(req.NamePrefix))
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+func encodePageToken(c *pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token, query string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "malformed page token")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "malformed page token")
+	}
+	if c.Query != query {
+		return nil, status.Error(codes.InvalidArgument, "page token does not match the request filters")
+	}
+	return &c, nil
+}
+
+// matchesFilters reports whether ticket, owned by user, passes the request's
+// filters.
+func matchesFilters(ticket *model.Ticket, user *model.User, req *model.ViewUsersBySectionRequest) bool {
+	if req.Section != "" && ticket.Section != req.Section {
+		return false
+	}
+	if req.TripId != "" && ticket.TripId != req.TripId {
+		return false
+	}
+	if req.From != "" && ticket.From != req.From {
+		return false
+	}
+	if req.To != "" && ticket.To != req.To {
+		return false
+	}
+	if req.Email != "" && !strings.EqualFold(user.GetEmail(), req.Email) {
+		return false
+	}
+	if req.NamePrefix != "" {
+		prefix := strings.ToLower(req.NamePrefix)
+		if !strings.HasPrefix(strings.ToLower(user.GetFirstName()), prefix) &&
+			!strings.HasPrefix(strings.ToLower(user.GetLastName()), prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortKey returns the key ticket, booked on t, is ordered by. Ties are broken
+// by ticket number. Callers must hold t.mu.
+func sortKey(t *trip, ticket *model.Ticket, user *model.User, order model.TicketSortOrder) string {
+	switch order {
+	case model.TicketSortOrder_TICKET_SORT_ORDER_TICKET_NUMBER:
+		return ""
+	case model.TicketSortOrder_TICKET_SORT_ORDER_SURNAME:
+		return strings.ToLower(user.GetLastName()) + "\x00" + strings.ToLower(user.GetFirstName())
+	default:
+		index := 0
+		if st, exists := t.byNumber[ticket.SeatNumber]; exists {
+			index = st.index
+		}
+		return fmt.Sprintf("%s\x00%06d", ticket.TripId, index)
+	}
+}
+
+// listTickets returns one page of the tickets matching req, and the token for
+// the next page.
+func (s *TicketServiceServer) listTickets(req *model.ViewUsersBySectionRequest) ([]*model.Ticket, string, error) {
+	if req.PageSize < 0 {
+		return nil, "", status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	pageSize := int(req.PageSize)
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	} else if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	query := queryFingerprint(req)
+	var cursor *pageCursor
+	if req.PageToken != "" {
+		var err error
+		if cursor, err = decodePageToken(req.PageToken, query); err != nil {
+			return nil, "", err
+		}
+	}
+
+	trips := s.allTrips()
+	unlock := lockTrips(trips, false)
+	var listed []listedTicket
+	for _, t := range trips {
+		if req.TripId != "" && t.id != req.TripId {
+			continue
+		}
+		for _, ticket := range t.tickets {
+			ticket = s.withUser(ticket)
+			if !matchesFilters(ticket, ticket.User, req) {
+				continue
+			}
+			l := listedTicket{ticket: ticket, key: sortKey(t, ticket, ticket.User, req.SortBy)}
+			if cursor != nil && !l.after(cursor) {
+				continue
+			}
+			listed = append(listed, l)
+		}
+	}
+	unlock()
+	sort.Slice(listed, func(i, j int) bool {
+		if listed[i].key != listed[j].key {
+			return listed[i].key < listed[j].key
+		}
+		return listed[i].ticket.TicketNumber < listed[j].ticket.TicketNumber
+	})
+
+	var nextPageToken string
+	if len(listed) > pageSize {
+		listed = listed[:pageSize]
+		last := listed[pageSize-1]
+		nextPageToken = encodePageToken(&pageCursor{Query: query, Key: last.key, Ticket: last.ticket.TicketNumber})
+	}
+
+	tickets := make([]*model.Ticket, 0, len(listed))
+	for _, l := range listed {
+		tickets = append(tickets, l.ticket)
+	}
+	return tickets, nextPageToken, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func purchaseAs(server *TicketServiceServer, first, last string) *model.PurchaseResponse {
+	req := &model.PurchaseRequest{
+		From: "City A",
+		To:   "City B",
+		User: &model.User{FirstName: first, LastName: last, Email: fmt.Sprintf("%s@example.com", first)},
+	}
+	res, _ := server.PurchaseTicket(ownerContext(server, req), req)
+	return res
+}
+
+func ticketNumbers(tickets []*model.Ticket) []int32 {
+	var numbers []int32
+	for _, ticket := range tickets {
+		numbers = append(numbers, ticket.TicketNumber)
+	}
+	return numbers
+}
+
+func TestViewUsersBySectionPaginationSurvivesInserts(t *testing.T) {
+	server := NewTicketServiceServer()
+	for i := 1; i <= 5; i++ {
+		purchaseAs(server, fmt.Sprintf("user%d", i), "Doe")
+	}
+
+	req := &model.ViewUsersBySectionRequest{Section: "A", PageSize: 2}
+	res, err := server.ViewUsersBySection(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, ticketNumbers(res.Tickets))
+	assert.NotEmpty(t, res.NextPageToken)
+
+	// Free a seat already listed and refill it; the new ticket sorts before
+	// the cursor and must not disturb the following pages.
+	_, _ = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 1})
+	purchaseAs(server, "late", "Doe")
+
+	req.PageToken = res.NextPageToken
+	res, err = server.ViewUsersBySection(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{3, 4}, ticketNumbers(res.Tickets))
+
+	req.PageToken = res.NextPageToken
+	res, err = server.ViewUsersBySection(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{5}, ticketNumbers(res.Tickets))
+	assert.Empty(t, res.NextPageToken)
+}
+
+func TestViewUsersBySectionSortAndFilter(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	staff := asStaff("secret")
+	purchaseAs(server, "carol", "Young")
+	purchaseAs(server, "alice", "Adams")
+	purchaseAs(server, "bob", "Baker")
+	purchaseAs(server, "abe", "Zane")
+
+	res, err := server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{
+		Section: "A",
+		SortBy:  model.TicketSortOrder_TICKET_SORT_ORDER_SURNAME,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{2, 3, 1, 4}, ticketNumbers(res.Tickets))
+
+	res, _ = server.ViewUsersBySection(staff, &model.ViewUsersBySectionRequest{NamePrefix: "A"})
+	assert.Equal(t, []int32{2, 4}, ticketNumbers(res.Tickets))
+
+	res, _ = server.ViewUsersBySection(staff, &model.ViewUsersBySectionRequest{Email: "BOB@example.com"})
+	assert.Equal(t, []int32{3}, ticketNumbers(res.Tickets))
+
+	res, _ = server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{From: "City Z"})
+	assert.Empty(t, res.Tickets)
+
+	page, _ := server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{PageSize: 1})
+	_, err = server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{
+		PageSize:  1,
+		PageToken: page.NextPageToken,
+		SortBy:    model.TicketSortOrder_TICKET_SORT_ORDER_SURNAME,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestViewUsersBySectionSearchIsStaffOnly(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	purchaseAs(server, "alice", "Adams")
+
+	_, err := server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{Email: "alice@example.com"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.ViewUsersBySection(asUser(server, "alice"), &model.ViewUsersBySectionRequest{NamePrefix: "A"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.ViewUsersBySection(asStaff("wrong"), &model.ViewUsersBySectionRequest{NamePrefix: "A"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	res, err := server.ViewUsersBySection(asStaff("secret"), &model.ViewUsersBySectionRequest{Email: "alice@example.com"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Tickets, 1)
+}
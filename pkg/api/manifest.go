@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ExportManifest implementation
+func (s *TicketServiceServer) ExportManifest(ctx context.Context, req *model.ExportManifestRequest) (*model.ExportManifestResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	manifest := s.buildManifest(t)
+	t.mu.RUnlock()
+
+	var content []byte
+	var contentType, ext string
+	switch req.Format {
+	case model.ManifestFormat_MANIFEST_FORMAT_UNSPECIFIED, model.ManifestFormat_MANIFEST_FORMAT_CSV:
+		content, err = manifestCSV(manifest)
+		contentType, ext = "text/csv", "csv"
+	case model.ManifestFormat_MANIFEST_FORMAT_JSON:
+		content, err = protojson.MarshalOptions{Multiline: true}.Marshal(manifest)
+		contentType, ext = "application/json", "json"
+	case model.ManifestFormat_MANIFEST_FORMAT_HTML:
+		content, err = manifestHTML(manifest)
+		contentType, ext = "text/html; charset=utf-8", "html"
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported manifest format: %v", req.Format)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to render manifest: %v", err)
+	}
+
+	return &model.ExportManifestResponse{
+		ContentType: contentType,
+		Filename:    fmt.Sprintf("manifest-%s.%s", t.id, ext),
+		Content:     content,
+	}, nil
+}
+
+// buildManifest lists the passengers of t by section, in seat order.
+// Callers must hold t.mu.
+func (s *TicketServiceServer) buildManifest(t *trip) *model.Manifest {
+	manifest := &model.Manifest{TripId: t.id, GeneratedAt: timestamppb.Now()}
+	for _, section := range t.sections {
+		ms := &model.ManifestSection{Section: section}
+		for _, st := range t.seats {
+			if st.section != section || st.ticket == 0 {
+				continue
+			}
+			ms.Entries = append(ms.Entries, s.manifestEntry(t.tickets[st.ticket]))
+		}
+		manifest.Sections = append(manifest.Sections, ms)
+	}
+	unassigned := &model.ManifestSection{Section: manifestUnassigned}
+	for _, section := range t.sections {
+		for _, ticket := range t.unassigned(section) {
+			unassigned.Entries = append(unassigned.Entries, s.manifestEntry(ticket))
+		}
+	}
+	if len(unassigned.Entries) > 0 {
+		manifest.Sections = append(manifest.Sections, unassigned)
+	}
+	if len(t.waitlist) > 0 {
+		ms := &model.ManifestSection{Section: manifestWaitlist}
+		for _, number := range t.waitlist {
+			ms.Entries = append(ms.Entries, s.manifestEntry(t.tickets[number]))
+		}
+		manifest.Sections = append(manifest.Sections, ms)
+	}
+	return manifest
+}
+
+const (
+	// manifestUnassigned lists overbooked tickets yet to be given a seat.
+	manifestUnassigned = "Unassigned"
+	// manifestWaitlist lists tickets waiting for a seat to free up.
+	manifestWaitlist = "Waitlist"
+)
+
+func (s *TicketServiceServer) manifestEntry(ticket *model.Ticket) *model.ManifestEntry {
+	ticket = s.withUser(ticket)
+	return &model.ManifestEntry{
+		SeatNumber:      ticket.SeatNumber,
+		TicketNumber:    ticket.TicketNumber,
+		PassengerName:   strings.TrimSpace(ticket.User.GetFirstName() + " " + ticket.User.GetLastName()),
+		Email:           ticket.User.GetEmail(),
+		From:            ticket.From,
+		To:              ticket.To,
+		AssistanceNeeds: ticket.AssistanceNeeds,
+		Status:          ticket.Status,
+	}
+}
+
+func manifestCSV(manifest *model.Manifest) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"section", "seat_number", "ticket_number", "passenger_name", "email", "from", "to", "assistance_needs", "status"})
+	for _, section := range manifest.Sections {
+		for _, entry := range section.Entries {
+			w.Write([]string{
+				csvCell(section.Section),
+				csvCell(entry.SeatNumber),
+				strconv.Itoa(int(entry.TicketNumber)),
+				csvCell(entry.PassengerName),
+				csvCell(entry.Email),
+				csvCell(entry.From),
+				csvCell(entry.To),
+				csvCell(strings.Join(entry.AssistanceNeeds, ";")),
+				statusName(entry.Status),
+			})
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvCell quotes a value that a spreadsheet would run as a formula, so
+// passenger details cannot carry one onto staff machines.
+func csvCell(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+var manifestTemplate = template.Must(template.New("manifest").Funcs(template.FuncMap{
+	"join":   strings.Join,
+	"status": statusName,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Manifest {{.TripId}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+.assist { font-weight: bold; }
+@media print { section { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Passenger manifest: {{.TripId}}</h1>
+<p>Generated {{.GeneratedAt.AsTime.Format "2006-01-02 15:04 MST"}}</p>
+{{range .Sections}}<section>
+<h2>Section {{.Section}}</h2>
+<table>
+<tr><th>Seat</th><th>Ticket</th><th>Passenger</th><th>Email</th><th>From</th><th>To</th><th>Assistance</th><th>Status</th></tr>
+{{range .Entries}}<tr><td>{{.SeatNumber}}</td><td>{{.TicketNumber}}</td><td>{{.PassengerName}}</td><td>{{.Email}}</td><td>{{.From}}</td><td>{{.To}}</td><td class="assist">{{join .AssistanceNeeds ", "}}</td><td>{{status .Status}}</td></tr>
+{{else}}<tr><td colspan="8">No passengers</td></tr>
+{{end}}</table>
+</section>
+{{end}}</body>
+</html>
+`))
+
+func manifestHTML(manifest *model.Manifest) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := manifestTemplate.Execute(&buf, manifest); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestExportManifest(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	first := purchaseAs(server, "alice", "Doe")
+	_, _ = server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		From:            "City A",
+		To:              "City C",
+		User:            &model.User{FirstName: "Bob", LastName: "Roe", Email: "bob@example.com"},
+		AssistanceNeeds: []string{"wheelchair"},
+	})
+	_, _ = server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		From: "City A",
+		To:   "City B",
+		User: &model.User{FirstName: "=HYPERLINK(\"http://x\")", LastName: "Doe", Email: "@eve@example.com"},
+	})
+	_, _ = server.ModifyUserSeat(ownerOf(server, first.TicketNumber), &model.ModifySeatRequest{TicketNumber: first.TicketNumber, NewSection: "B"})
+
+	_, err := server.ExportManifest(context.Background(), &model.ExportManifestRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	res, err := server.ExportManifest(asStaff("secret"), &model.ExportManifestRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv", res.ContentType)
+	assert.Equal(t, "manifest-default.csv", res.Filename)
+	assert.Equal(t, strings.Join([]string{
+		"section,seat_number,ticket_number,passenger_name,email,from,to,assistance_needs,status",
+		"A,1B,2,Bob Roe,bob@example.com,City A,City C,wheelchair,booked",
+		`A,1C,3,"'=HYPERLINK(""http://x"") Doe",'@eve@example.com,City A,City B,,booked`,
+		"B,2A,1,alice Doe,alice@example.com,City A,City B,,booked",
+		"",
+	}, "\n"), string(res.Content))
+
+	res, err = server.ExportManifest(asStaff("secret"), &model.ExportManifestRequest{Format: model.ManifestFormat_MANIFEST_FORMAT_JSON})
+	assert.NoError(t, err)
+	var manifest model.Manifest
+	assert.NoError(t, protojson.Unmarshal(res.Content, &manifest))
+	assert.Len(t, manifest.Sections, 2)
+	assert.Equal(t, []string{"wheelchair"}, manifest.Sections[0].Entries[0].AssistanceNeeds)
+
+	res, err = server.ExportManifest(asStaff("secret"), &model.ExportManifestRequest{Format: model.ManifestFormat_MANIFEST_FORMAT_HTML})
+	assert.NoError(t, err)
+	assert.Contains(t, string(res.Content), "<h2>Section A</h2>")
+	assert.Contains(t, string(res.Content), "<td>Bob Roe</td>")
+}
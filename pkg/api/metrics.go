@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// rpcMetrics records per-RPC latency and errors through gRPC interceptors.
+type rpcMetrics struct {
+	latency *prometheus.HistogramVec
+	errors  *prometheus.CounterVec
+}
+
+func newRPCMetrics() *rpcMetrics {
+	return &rpcMetrics{
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "trainticket_rpc_duration_seconds",
+			Help:    "Latency of gRPC calls by method and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "trainticket_rpc_errors_total",
+			Help: "gRPC calls that returned an error, by method and status code.",
+		}, []string{"method", "code"}),
+	}
+}
+
+func (m *rpcMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.latency.Describe(ch)
+	m.errors.Describe(ch)
+}
+
+func (m *rpcMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.latency.Collect(ch)
+	m.errors.Collect(ch)
+}
+
+func (m *rpcMetrics) observe(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	m.latency.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(method, code).Inc()
+	}
+}
+
+// UnaryServerInterceptor returns an interceptor recording unary calls.
+func (m *rpcMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor recording streaming calls
+// over their whole lifetime.
+func (m *rpcMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.observe(info.FullMethod, start, err)
+		return err
+	}
+}
+
+// salesMetrics holds the business counters updated by the ticket handlers.
+type salesMetrics struct {
+	ticketsSold   prometheus.Counter
+	cancellations prometheus.Counter
+	revenue       prometheus.Counter
+	refunds       prometheus.Counter
+}
+
+func newSalesMetrics() *salesMetrics {
+	return &salesMetrics{
+		ticketsSold: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "trainticket_tickets_sold_total",
+			Help: "Tickets purchased.",
+		}),
+		cancellations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "trainticket_cancellations_total",
+			Help: "Tickets removed after purchase.",
+		}),
+		revenue: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "trainticket_revenue_total",
+			Help: "Sum of prices paid for purchased tickets.",
+		}),
+		refunds: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "trainticket_refunds_total",
+			Help: "Sum of fare differences refunded.",
+		}),
+	}
+}
+
+var seatsAvailableDesc = prometheus.NewDesc(
+	"trainticket_seats_available",
+	"Seats currently available for purchase, by trip and section.",
+	[]string{"trip", "section"}, nil,
+)
+
+var waitlistDesc = prometheus.NewDesc(
+	"trainticket_waitlist_size",
+	"Tickets waiting for a seat, by trip.",
+	[]string{"trip"}, nil,
+)
+
+// Describe implements prometheus.Collector.
+func (s *TicketServiceServer) Describe(ch chan<- *prometheus.Desc) {
+	ch <- seatsAvailableDesc
+	ch <- waitlistDesc
+	s.metrics.ticketsSold.Describe(ch)
+	s.metrics.cancellations.Describe(ch)
+	s.metrics.revenue.Describe(ch)
+	s.metrics.refunds.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. Seat availability is read from
+// the inventory at scrape time so it can never drift from the real state.
+func (s *TicketServiceServer) Collect(ch chan<- prometheus.Metric) {
+	for _, t := range s.allTrips() {
+		t.mu.RLock()
+		for _, section := range t.sections {
+			ch <- prometheus.MustNewConstMetric(seatsAvailableDesc, prometheus.GaugeValue, float64(t.freeSeats(section)), t.id, section)
+		}
+		ch <- prometheus.MustNewConstMetric(waitlistDesc, prometheus.GaugeValue, float64(len(t.waitlist)), t.id)
+		t.mu.RUnlock()
+	}
+
+	s.metrics.ticketsSold.Collect(ch)
+	s.metrics.cancellations.Collect(ch)
+	s.metrics.revenue.Collect(ch)
+	s.metrics.refunds.Collect(ch)
+}
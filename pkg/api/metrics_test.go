@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSalesMetrics(t *testing.T) {
+	server := NewTicketServiceServer()
+
+	for i := 1; i <= 3; i++ {
+		_, _ = server.PurchaseTicket(asUser(server, "U1"), &model.PurchaseRequest{
+			From: "City A",
+			To:   "City B",
+			User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+		})
+	}
+	_, _ = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 1})
+
+	expected := `
+# HELP trainticket_cancellations_total Tickets removed after purchase.
+# TYPE trainticket_cancellations_total counter
+trainticket_cancellations_total 1
+# HELP trainticket_refunds_total Sum of fare differences refunded.
+# TYPE trainticket_refunds_total counter
+trainticket_refunds_total 0
+# HELP trainticket_revenue_total Sum of prices paid for purchased tickets.
+# TYPE trainticket_revenue_total counter
+trainticket_revenue_total 60
+# HELP trainticket_seats_available Seats currently available for purchase, by trip and section.
+# TYPE trainticket_seats_available gauge
+trainticket_seats_available{section="A",trip="default"} 8
+trainticket_seats_available{section="B",trip="default"} 10
+# HELP trainticket_tickets_sold_total Tickets purchased.
+# TYPE trainticket_tickets_sold_total counter
+trainticket_tickets_sold_total 3
+# HELP trainticket_waitlist_size Tickets waiting for a seat, by trip.
+# TYPE trainticket_waitlist_size gauge
+trainticket_waitlist_size{trip="default"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(server, strings.NewReader(expected)))
+}
+
+func TestRPCMetricsInterceptor(t *testing.T) {
+	metrics := newRPCMetrics()
+	interceptor := metrics.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: model.TicketService_GetReceipt_FullMethodName}
+
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+	_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.errors.WithLabelValues(info.FullMethod, codes.NotFound.String())))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.latency))
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Notification tells a passenger that their ticket's seat has changed.
+type Notification struct {
+	UserID       string
+	TicketNumber int32
+	TripID       string
+	OldSeat      string // Empty if the ticket had no seat
+	NewSeat      string // Empty if the ticket was waitlisted
+	Message      string
+}
+
+// Notifier delivers notifications to passengers. Implementations must be
+// safe for concurrent use.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// WithNotifier sets how passengers are notified of seat changes they did not
+// make themselves. The default writes each notification to the log.
+func WithNotifier(n Notifier) Option {
+	return func(s *TicketServiceServer) { s.notifier = n }
+}
+
+// logNotifier writes notifications to the default logger, for running
+// without a real delivery channel.
+type logNotifier struct{}
+
+func (logNotifier) Notify(ctx context.Context, n Notification) error {
+	slog.InfoContext(ctx, "passenger notification",
+		"user_id", n.UserID,
+		"ticket_number", n.TicketNumber,
+		"trip_id", n.TripID,
+		"old_seat", n.OldSeat,
+		"new_seat", n.NewSeat,
+		"message", n.Message,
+	)
+	return nil
+}
+
+// notify delivers notes in the background, so callers may hold locks and
+// are not slowed down by delivery. Failures are logged.
+func (s *TicketServiceServer) notify(ctx context.Context, notes ...Notification) {
+	if len(notes) == 0 {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for _, n := range notes {
+			if err := s.notifier.Notify(ctx, n); err != nil {
+				slog.ErrorContext(ctx, "failed to notify passenger", "ticket_number", n.TicketNumber, "error", err)
+			}
+		}
+	}()
+}
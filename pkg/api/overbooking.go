@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WithOverbooking sets the percentage of extra tickets, beyond their seats,
+// that sections sell unless staff set otherwise with SetOverbooking. The
+// default is zero, which never sells a ticket without a seat.
+func WithOverbooking(percent int) Option {
+	return func(s *TicketServiceServer) { s.overbooking = int32(percent) }
+}
+
+// SetOverbooking implementation
+func (s *TicketServiceServer) SetOverbooking(ctx context.Context, req *model.SetOverbookingRequest) (*model.SetOverbookingResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	if req.Percent < 0 || req.Percent > 100 {
+		return nil, status.Error(codes.InvalidArgument, "percent must be between 0 and 100")
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sections := t.sections
+	if req.Section != "" {
+		if _, exists := t.classes[req.Section]; !exists {
+			return nil, status.Errorf(codes.NotFound, "section not found: %s", req.Section)
+		}
+		sections = []string{req.Section}
+	}
+	// Tickets already sold are kept even if the new limit is lower
+	res := &model.SetOverbookingResponse{}
+	for _, section := range sections {
+		t.overbooking[section] = req.Percent
+		res.Policies = append(res.Policies, s.overbookingPolicy(t, section))
+	}
+	return res, nil
+}
+
+// GetOverbooking implementation
+func (s *TicketServiceServer) GetOverbooking(ctx context.Context, req *model.GetOverbookingRequest) (*model.GetOverbookingResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	res := &model.GetOverbookingResponse{}
+	for _, section := range t.sections {
+		res.Policies = append(res.Policies, s.overbookingPolicy(t, section))
+	}
+	return res, nil
+}
+
+// overbookingPolicy reports how far section of t may be overbooked and how
+// much of that allowance is sold. Callers must hold t.mu.
+func (s *TicketServiceServer) overbookingPolicy(t *trip, section string) *model.OverbookingPolicy {
+	percent, exists := t.overbooking[section]
+	if !exists {
+		percent = s.overbooking
+	}
+	now := time.Now()
+	var capacity int32
+	for _, st := range t.seats {
+		if st.section == section && !st.blocked(now) {
+			capacity++
+		}
+	}
+	return &model.OverbookingPolicy{
+		Section:    section,
+		Percent:    percent,
+		Capacity:   capacity,
+		Limit:      capacity * percent / 100,
+		Unassigned: int32(len(t.unassigned(section))),
+	}
+}
+
+// overbookSection returns the first section of class, or of any class if it
+// is unspecified, with overbooking allowance left, or "" if there is none.
+// Callers must hold t.mu.
+func (s *TicketServiceServer) overbookSection(t *trip, class model.TravelClass) string {
+	for _, section := range t.sections {
+		if class != model.TravelClass_TRAVEL_CLASS_UNSPECIFIED && t.classes[section] != class {
+			continue
+		}
+		if policy := s.overbookingPolicy(t, section); policy.Unassigned < policy.Limit {
+			return section
+		}
+	}
+	return ""
+}
+
+// unassigned returns the booked overbooked tickets of section still without
+// a seat, earliest purchase first. Callers must hold t.mu.
+func (t *trip) unassigned(section string) []*model.Ticket {
+	var tickets []*model.Ticket
+	for _, ticket := range t.tickets {
+		if ticket.Overbooked && ticket.SeatNumber == "" && ticket.Section == section && ticket.Status == model.TicketStatus_TICKET_STATUS_BOOKED {
+			tickets = append(tickets, ticket)
+		}
+	}
+	slices.SortFunc(tickets, func(a, b *model.Ticket) int { return int(a.TicketNumber - b.TicketNumber) })
+	return tickets
+}
+
+// seatFor returns a free seat for a ticket without one: in its section if
+// possible, else elsewhere in its travel class, else nil. Callers must hold
+// t.mu.
+func (t *trip) seatFor(ticket *model.Ticket) *seat {
+	if st := t.freeSeat(ticket.Section); st != nil {
+		return st
+	}
+	st, _ := t.pick(seatChoice{class: ticket.TravelClass})
+	return st
+}
+
+// assignSeat gives st to a ticket without a seat and returns the
+// availability change to publish. Callers bump the ticket's version and
+// must hold t.mu for writing.
+func (t *trip) assignSeat(ticket *model.Ticket, st *seat, actor string) *model.SeatChange {
+	recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_ASSIGNED, actor, "unassigned -> "+st.number)
+	st.ticket = ticket.TicketNumber
+	ticket.SeatNumber, ticket.Section = st.number, st.section
+	ticket.TravelClass = t.classes[st.section]
+	return &model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number}
+}
+
+// OffloadSection implementation
+func (s *TicketServiceServer) OffloadSection(ctx context.Context, req *model.OffloadSectionRequest) (*model.OffloadSectionResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return idempotent(ctx, s.idempotency, "OffloadSection", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.OffloadSectionResponse, error) {
+		return s.offloadSection(ctx, req)
+	})
+}
+
+// offloadSection settles the overbooked tickets of a section: each is given
+// a free seat of its travel class while there are any, earliest purchase
+// first, and the rest are offloaded. An offloaded passenger is rebooked on
+// the rebooking trip if it has a seat in their class, and refunded
+// otherwise; either way they are paid the compensation and told.
+func (s *TicketServiceServer) offloadSection(ctx context.Context, req *model.OffloadSectionRequest) (*model.OffloadSectionResponse, error) {
+	if req.Compensation < 0 {
+		return nil, status.Error(codes.InvalidArgument, "compensation must not be negative")
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	trips := []*trip{t}
+	var rebook *trip
+	if req.RebookTripId != "" {
+		if rebook, err = s.trip(req.RebookTripId); err != nil {
+			return nil, err
+		}
+		if rebook == t {
+			return nil, status.Error(codes.InvalidArgument, "rebook_trip_id must be another trip")
+		}
+		trips = s.inOrder(t, rebook)
+	}
+
+	unlock := lockTrips(trips, true)
+	if _, exists := t.classes[req.Section]; !exists {
+		unlock()
+		return nil, status.Errorf(codes.NotFound, "section not found: %s", req.Section)
+	}
+	res := &model.OffloadSectionResponse{}
+	var changes []*model.SeatChange
+	var notes []Notification
+	actor := s.actor(ctx)
+	for _, ticket := range t.unassigned(req.Section) {
+		if st := t.seatFor(ticket); st != nil {
+			changes = append(changes, t.assignSeat(ticket, st, actor))
+			ticket.Version++
+			res.Seated = append(res.Seated, &model.Relocation{
+				TicketNumber:  ticket.TicketNumber,
+				UserId:        ticket.UserId,
+				OldSection:    req.Section,
+				NewSeatNumber: st.number,
+				NewSection:    st.section,
+			})
+			notes = append(notes, Notification{
+				UserID:       ticket.UserId,
+				TicketNumber: ticket.TicketNumber,
+				TripID:       t.id,
+				NewSeat:      st.number,
+				Message:      fmt.Sprintf("Your seat is %s.", st.number),
+			})
+			continue
+		}
+
+		offload := &model.Offload{
+			TicketNumber: ticket.TicketNumber,
+			UserId:       ticket.UserId,
+			Compensation: req.Compensation,
+		}
+		note := Notification{UserID: ticket.UserId, TicketNumber: ticket.TicketNumber, TripID: t.id}
+		var dest *seat
+		if rebook != nil {
+			dest, _ = rebook.pick(seatChoice{class: ticket.TravelClass})
+		}
+		delete(t.tickets, ticket.TicketNumber)
+		if dest != nil {
+			number := s.lastTicket.Add(1)
+			rebooked := reissue(ticket, number, rebook, dest)
+			rebooked.PricePaid = ticket.PricePaid
+			recordEvent(rebooked, model.TicketEventType_TICKET_EVENT_TYPE_OFFLOADED, actor,
+				fmt.Sprintf("rebooked from ticket %d, trip %s", ticket.TicketNumber, t.id))
+			recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_OFFLOADED, actor,
+				fmt.Sprintf("rebooked as ticket %d, trip %s seat %s", number, rebook.id, dest.number))
+			setStatus(ticket, model.TicketStatus_TICKET_STATUS_CANCELLED, actor, "")
+			dest.ticket = number
+			ticket.ExchangedTo = number
+			rebook.tickets[number] = rebooked
+			s.ticketsMu.Lock()
+			s.ticketTrips[number] = rebook
+			s.ticketsMu.Unlock()
+			changes = append(changes, &model.SeatChange{TripId: rebook.id, Section: dest.section, SeatNumber: dest.number})
+			offload.RebookedTicketNumber, offload.RebookedSeatNumber = number, dest.number
+			note.Message = fmt.Sprintf("Your train is overbooked. You have been rebooked on trip %s, seat %s, as ticket %d.", rebook.id, dest.number, number)
+		} else {
+			offload.Refund = ticket.PricePaid
+			setStatus(ticket, model.TicketStatus_TICKET_STATUS_REFUNDED, actor, "offloaded")
+			s.metrics.cancellations.Inc()
+			note.Message = fmt.Sprintf("Your train is overbooked and no seat could be found for you. Your fare of %.2f is being refunded.", ticket.PricePaid)
+		}
+		if req.Compensation > 0 {
+			note.Message += fmt.Sprintf(" You will receive %.2f in compensation.", req.Compensation)
+		}
+		t.receipts[ticket.TicketNumber] = ticket
+		ticket.Version++
+		res.Offloaded = append(res.Offloaded, offload)
+		notes = append(notes, note)
+	}
+	if len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	s.notify(ctx, notes...)
+	unlock()
+
+	// Pay without holding the locks; the offloads stand even if paying fails
+	ctx = context.WithoutCancel(ctx)
+	for _, offload := range res.Offloaded {
+		amount := roundFare(offload.Refund + offload.Compensation)
+		if amount == 0 {
+			continue
+		}
+		payment := Payment{
+			UserID:    offload.UserId,
+			Amount:    amount,
+			Reference: fmt.Sprintf("ticket %d offload", offload.TicketNumber),
+		}
+		paymentID, err := s.settle(ctx, payment, -amount)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to pay offloaded passenger", "ticket_number", offload.TicketNumber, "amount", amount, "error", err)
+			continue
+		}
+		offload.PaymentId = paymentID
+		s.metrics.refunds.Add(float64(amount))
+	}
+	return res, nil
+}
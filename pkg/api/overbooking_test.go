@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// purchaseOnTrip buys a ticket on tripID for a passenger named first.
+func purchaseOnTrip(server *TicketServiceServer, tripID, first string) (*model.PurchaseResponse, error) {
+	req := &model.PurchaseRequest{
+		From:   "City A",
+		To:     "City B",
+		TripId: tripID,
+		User:   &model.User{FirstName: first, LastName: "Doe", Email: fmt.Sprintf("%s@example.com", first)},
+	}
+	return server.PurchaseTicket(ownerContext(server, req), req)
+}
+
+func TestOverbookingSellsUnassignedTickets(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"1A", "1B", "1C", "1D"}}))
+
+	_, err := server.SetOverbooking(context.Background(), &model.SetOverbookingRequest{TripId: "T2", Percent: 50})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "T2", Percent: 150})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "T2", Section: "Z", Percent: 50})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	policies, err := server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "T2", Percent: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.OverbookingPolicy{{Section: "C", Percent: 50, Capacity: 4, Limit: 2}}, policies.Policies)
+
+	for _, first := range []string{"a", "b", "c", "d"} {
+		res, err := purchaseOnTrip(server, "T2", first)
+		assert.NoError(t, err)
+		assert.False(t, res.Overbooked)
+	}
+
+	// Two tickets fit in the allowance once every seat is sold
+	for _, first := range []string{"e", "f"} {
+		res, err := purchaseOnTrip(server, "T2", first)
+		assert.NoError(t, err)
+		assert.True(t, res.Overbooked)
+		assert.Equal(t, "C", res.Section)
+		assert.Empty(t, res.SeatNumber)
+		assert.Equal(t, float32(20), res.PricePaid)
+	}
+	_, err = purchaseOnTrip(server, "T2", "g")
+	assert.EqualError(t, err, "no available seats in any of sections")
+
+	// A freed seat is sold again; overbooked tickets wait for check-in
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 1})
+	assert.NoError(t, err)
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 5})
+	assert.Empty(t, receipt.Ticket.SeatNumber)
+	assert.True(t, receipt.Ticket.Overbooked)
+	res, err := purchaseOnTrip(server, "T2", "g")
+	assert.NoError(t, err)
+	assert.Equal(t, "1A", res.SeatNumber)
+
+	got, err := server.GetOverbooking(asStaff("secret"), &model.GetOverbookingRequest{TripId: "T2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), got.Policies[0].Unassigned)
+	manifest, err := server.ExportManifest(asStaff("secret"), &model.ExportManifestRequest{TripId: "T2"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(manifest.Content), "Unassigned,,5,")
+
+	// The default trip is not overbooked unless configured
+	got, _ = server.GetOverbooking(asStaff("secret"), &model.GetOverbookingRequest{})
+	assert.Equal(t, int32(0), got.Policies[0].Limit)
+	got, _ = NewTicketServiceServer(WithStaffKey("secret"), WithOverbooking(10)).GetOverbooking(asStaff("secret"), &model.GetOverbookingRequest{})
+	assert.Equal(t, int32(1), got.Policies[0].Limit)
+}
+
+func TestOffloadSection(t *testing.T) {
+	payments := &fakePayments{}
+	notes := make(fakeNotifier, 10)
+	server := NewTicketServiceServer(WithStaffKey("secret"), WithPaymentProcessor(payments), WithNotifier(notes))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"1A", "1B"}}))
+	server.addTrip(newTrip("T3", []string{"D"}, map[string][]string{"D": {"2A"}}))
+	_, err := server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "T2", Percent: 100})
+	assert.NoError(t, err)
+	for _, first := range []string{"a", "b", "c", "d", "e"} {
+		_, err := purchaseOnTrip(server, "T2", first)
+		if first == "e" {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	// One passenger cancels, so the earliest overbooked ticket gets a seat
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 2})
+	assert.NoError(t, err)
+	_, err = server.OffloadSection(context.Background(), &model.OffloadSectionRequest{TripId: "T2", Section: "C"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	res, err := server.OffloadSection(asStaff("secret"), &model.OffloadSectionRequest{
+		TripId:       "T2",
+		Section:      "C",
+		RebookTripId: "T3",
+		Compensation: 50,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Relocation{{TicketNumber: 3, UserId: "U3", OldSection: "C", NewSeatNumber: "1B", NewSection: "C"}}, res.Seated)
+	assert.Equal(t, []*model.Offload{
+		{TicketNumber: 4, UserId: "U4", RebookedTicketNumber: 5, RebookedSeatNumber: "2A", Compensation: 50, PaymentId: "refund"},
+	}, res.Offloaded)
+	assert.Equal(t, []Payment{{UserID: "U4", Amount: 50, Reference: "ticket 4 offload"}}, payments.refunds)
+
+	seated, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 3})
+	assert.Equal(t, "1B", seated.Ticket.SeatNumber)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_ASSIGNED, seated.Ticket.History[1].Type)
+	old, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 4})
+	assert.Equal(t, int32(5), old.Ticket.ExchangedTo)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_OFFLOADED, old.Ticket.History[1].Type)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_CANCELLED, old.Ticket.Status)
+	rebooked, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 5})
+	assert.Equal(t, "T3", rebooked.Ticket.TripId)
+	assert.Equal(t, float32(20), rebooked.Ticket.PricePaid)
+	assert.Equal(t, "1B", notes.next(t).NewSeat)
+	assert.Contains(t, notes.next(t).Message, "rebooked on trip T3, seat 2A")
+
+	// With the rebooking trip full too, offloaded tickets are refunded
+	_, err = purchaseOnTrip(server, "T2", "f")
+	assert.NoError(t, err)
+	res, err = server.OffloadSection(asStaff("secret"), &model.OffloadSectionRequest{TripId: "T2", Section: "C", RebookTripId: "T3"})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Seated)
+	assert.Equal(t, []*model.Offload{{TicketNumber: 6, UserId: "U5", Refund: 20, PaymentId: "refund"}}, res.Offloaded)
+	refunded, err := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, model.TicketStatus_TICKET_STATUS_REFUNDED, refunded.Ticket.Status)
+	assert.Contains(t, notes.next(t).Message, "fare of 20.00 is being refunded")
+}
+
+func TestOffloadSectionRebooksOnCreatedTrip(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"), WithPaymentProcessor(&fakePayments{}), WithNotifier(make(fakeNotifier, 10)))
+	for _, req := range []*model.CreateTripRequest{
+		{TripId: "morning", Sections: []*model.TripSection{{Section: "C", Seats: 1}}},
+		{TripId: "evening", Sections: []*model.TripSection{
+			{Section: "F", TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST, Seats: 1},
+			{Section: "S", Seats: 1},
+		}},
+	} {
+		_, err := server.CreateTrip(asStaff("secret"), req)
+		assert.NoError(t, err)
+	}
+	_, err := server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "morning", Percent: 100})
+	assert.NoError(t, err)
+	_, err = purchaseOnTrip(server, "morning", "a")
+	assert.NoError(t, err)
+	overbooked, err := purchaseOnTrip(server, "morning", "b")
+	assert.NoError(t, err)
+	assert.Empty(t, overbooked.SeatNumber)
+
+	// The standard ticket is rebooked in the later train's standard section
+	res, err := server.OffloadSection(asStaff("secret"), &model.OffloadSectionRequest{TripId: "morning", Section: "C", RebookTripId: "evening"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Offloaded, 1) {
+		assert.Equal(t, "S1", res.Offloaded[0].RebookedSeatNumber)
+		rebooked := receiptFor(server, res.Offloaded[0].RebookedTicketNumber)
+		assert.Equal(t, "evening", rebooked.TripId)
+		assert.Equal(t, model.TravelClass_TRAVEL_CLASS_STANDARD, rebooked.TravelClass)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Payment is money moved between a passenger and the operator.
+type Payment struct {
+	UserID    string
+	Amount    float32 // Always positive
+	Reference string  // What the payment is for, unique per attempt
+}
+
+// PaymentProcessor settles fare differences. Implementations must be safe for
+// concurrent use.
+type PaymentProcessor interface {
+	// Charge takes p.Amount from the passenger and returns the payment's ID.
+	Charge(ctx context.Context, p Payment) (string, error)
+	// Refund returns p.Amount to the passenger and returns the refund's ID.
+	Refund(ctx context.Context, p Payment) (string, error)
+}
+
+// WithPaymentProcessor sets the processor fare differences are settled with.
+// The default approves every payment without moving any money.
+func WithPaymentProcessor(p PaymentProcessor) Option {
+	return func(s *TicketServiceServer) { s.payments = p }
+}
+
+// approvingPayments approves every payment, for running without a real
+// payment provider.
+type approvingPayments struct {
+	last atomic.Int64
+}
+
+func (a *approvingPayments) Charge(ctx context.Context, p Payment) (string, error) {
+	return fmt.Sprintf("charge-%d", a.last.Add(1)), nil
+}
+
+func (a *approvingPayments) Refund(ctx context.Context, p Payment) (string, error) {
+	return fmt.Sprintf("refund-%d", a.last.Add(1)), nil
+}
+
+// recordPayment adds a settled payment to the ledger, as a charge if
+// difference is positive and a refund if it is negative.
+func (s *TicketServiceServer) recordPayment(id string, p Payment, difference float32) {
+	amount := p.Amount
+	if difference < 0 {
+		amount = -amount
+	}
+	s.ledgerMu.Lock()
+	defer s.ledgerMu.Unlock()
+	s.ledger = append(s.ledger, &model.PaymentRecord{
+		PaymentId: id,
+		UserId:    p.UserID,
+		Amount:    amount,
+		Reference: p.Reference,
+		At:        timestamppb.Now(),
+	})
+}
+
+// paymentsOf returns copies of the ledger records of a user.
+func (s *TicketServiceServer) paymentsOf(userID string) []*model.PaymentRecord {
+	s.ledgerMu.Lock()
+	defer s.ledgerMu.Unlock()
+
+	var records []*model.PaymentRecord
+	for _, record := range s.ledger {
+		if record.UserId == userID {
+			records = append(records, proto.Clone(record).(*model.PaymentRecord))
+		}
+	}
+	return records
+}
+
+// forgetPayer removes a user's ID from their ledger records, which are kept
+// as financial records.
+func (s *TicketServiceServer) forgetPayer(userID string) {
+	s.ledgerMu.Lock()
+	defer s.ledgerMu.Unlock()
+
+	for _, record := range s.ledger {
+		if record.UserId == userID {
+			record.UserId = ""
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultFares is what a ticket costs in each travel class unless configured
+// otherwise.
+var defaultFares = map[model.TravelClass]float32{
+	model.TravelClass_TRAVEL_CLASS_STANDARD: 20,
+	model.TravelClass_TRAVEL_CLASS_FIRST:    35,
+}
+
+// defaultChangeFee is charged for exchanging a ticket unless configured
+// otherwise.
+const defaultChangeFee = 5
+
+// WithFare sets the fare of a travel class.
+func WithFare(class model.TravelClass, fare float32) Option {
+	return func(s *TicketServiceServer) { s.fares[class] = fare }
+}
+
+// WithChangeFee sets the fee charged for exchanging a ticket for one on
+// another trip.
+func WithChangeFee(fee float32) Option {
+	return func(s *TicketServiceServer) { s.changeFee = fee }
+}
+
+// fare returns the price of a ticket in class.
+func (s *TicketServiceServer) fare(class model.TravelClass) (float32, error) {
+	fare, exists := s.fares[class]
+	if !exists {
+		return 0, status.Errorf(codes.FailedPrecondition, "no fare for %s class", className(class))
+	}
+	return fare, nil
+}
+
+// className returns the lower-case name of class, such as "first".
+func className(class model.TravelClass) string {
+	return strings.ToLower(strings.TrimPrefix(class.String(), "TRAVEL_CLASS_"))
+}
+
+// roundFare rounds an amount of money to whole cents.
+func roundFare(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
+
+// ChangeClass implementation
+func (s *TicketServiceServer) ChangeClass(ctx context.Context, req *model.ChangeClassRequest) (*model.ChangeClassResponse, error) {
+	return idempotent(ctx, s.idempotency, "ChangeClass", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.ChangeClassResponse, error) {
+		if req.TravelClass == model.TravelClass_TRAVEL_CLASS_UNSPECIFIED {
+			return nil, status.Error(codes.InvalidArgument, "travel_class is required")
+		}
+		change, err := s.changeClass(ctx, req.TicketNumber, seatChoice{class: req.TravelClass}, req.ExpectedVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &model.ChangeClassResponse{
+			Message:        "Travel class changed successfully.",
+			Ticket:         change.ticket,
+			FareDifference: change.fareDifference,
+			PaymentId:      change.paymentID,
+		}, nil
+	})
+}
+
+// classChange is the outcome of moving a ticket to another travel class.
+type classChange struct {
+	ticket         *model.Ticket // Copy of the updated ticket
+	fareDifference float32       // Charged if positive, refunded if negative
+	paymentID      string
+}
+
+// changeClass moves a ticket to the seat to, which must be in another travel
+// class, and settles the fare difference. The seat is held while the payment
+// is made so the trip is not locked meanwhile; if the ticket changes or the
+// seat is blocked before the move is committed, the payment is reversed.
+func (s *TicketServiceServer) changeClass(ctx context.Context, ticketNumber int32, to seatChoice, expectedVersion int64) (*classChange, error) {
+	trip, ticket, unlock := s.lockTicket(ticketNumber, true)
+	if ticket == nil {
+		return nil, status.Errorf(codes.NotFound, "ticket not found: %d", ticketNumber)
+	}
+	if err := s.requireOwnerOrStaff(ctx, ticket.UserId); err != nil {
+		unlock()
+		return nil, err
+	}
+	if err := checkVersion(ticket, expectedVersion); err != nil {
+		unlock()
+		return nil, err
+	}
+	if err := checkChangeable(ticket); err != nil {
+		unlock()
+		return nil, err
+	}
+	newSeat, err := trip.pick(to)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	fromClass, toClass := ticket.TravelClass, trip.classes[newSeat.section]
+	if fromClass == toClass {
+		unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "ticket %d is already in %s class", ticketNumber, className(toClass))
+	}
+	newFare, err := s.fare(toClass)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	difference := roundFare(newFare - ticket.PricePaid)
+	version := ticket.Version
+	payment := Payment{
+		UserID:    ticket.UserId,
+		Amount:    float32(math.Abs(float64(difference))),
+		Reference: fmt.Sprintf("ticket %d class change at version %d", ticketNumber, version),
+	}
+	newSeat.held = ticketNumber
+	s.feed.publish(&model.SeatChange{TripId: trip.id, Section: newSeat.section, SeatNumber: newSeat.number})
+	unlock()
+
+	paymentID, err := s.settle(ctx, payment, difference)
+	if err != nil {
+		s.releaseHold(ctx, trip, newSeat)
+		return nil, err
+	}
+
+	trip.mu.Lock()
+	ticket = trip.tickets[ticketNumber]
+	if ticket == nil || ticket.Version != version {
+		trip.mu.Unlock()
+		s.releaseHold(ctx, trip, newSeat)
+		s.reverse(ctx, payment, difference)
+		return nil, status.Errorf(codes.Aborted, "ticket %d changed while its payment was made", ticketNumber)
+	}
+	if newSeat.blocked(time.Now()) {
+		trip.mu.Unlock()
+		s.releaseHold(ctx, trip, newSeat)
+		s.reverse(ctx, payment, difference)
+		return nil, status.Errorf(codes.FailedPrecondition, "seat %s was blocked while the payment was made", newSeat.number)
+	}
+	defer trip.mu.Unlock()
+
+	recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_CLASS_CHANGED, s.actor(ctx),
+		fmt.Sprintf("%s %s -> %s %s, fare difference %+.2f", className(fromClass), seatOrWaitlist(ticket.SeatNumber), className(toClass), newSeat.number, difference))
+	if changes := trip.vacate(ticket); len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	newSeat.held = 0
+	newSeat.ticket = ticketNumber
+	ticket.SeatNumber = newSeat.number
+	ticket.Section = newSeat.section
+	ticket.TravelClass = toClass
+	ticket.PricePaid = newFare
+	ticket.Version++
+	s.serveWaitlist(ctx, trip)
+
+	if difference > 0 {
+		s.metrics.revenue.Add(float64(difference))
+	} else if difference < 0 {
+		s.metrics.refunds.Add(float64(-difference))
+	}
+
+	return &classChange{ticket: s.withUser(ticket), fareDifference: difference, paymentID: paymentID}, nil
+}
+
+// settle charges payment if difference is positive and refunds it if it is
+// negative, returning the payment's ID.
+func (s *TicketServiceServer) settle(ctx context.Context, payment Payment, difference float32) (string, error) {
+	var id string
+	var err error
+	switch {
+	case difference > 0:
+		id, err = s.payments.Charge(ctx, payment)
+	case difference < 0:
+		id, err = s.payments.Refund(ctx, payment)
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return "", err
+		}
+		return "", status.Errorf(codes.FailedPrecondition, "payment failed: %v", err)
+	}
+	if difference != 0 {
+		s.recordPayment(id, payment, difference)
+	}
+	return id, nil
+}
+
+// reverse undoes payment, settled for difference, when the change it paid
+// for could not be made. The reversal has a reference of its own, so the
+// processor does not take it for a retry of the original. The caller is
+// already failing, so a reversal that fails is logged for staff to settle.
+func (s *TicketServiceServer) reverse(ctx context.Context, payment Payment, difference float32) {
+	payment.Reference += " reversal"
+	if _, err := s.settle(context.WithoutCancel(ctx), payment, -difference); err != nil {
+		slog.ErrorContext(ctx, "failed to reverse payment", "user_id", payment.UserID, "reference", payment.Reference, "amount", payment.Amount, "error", err)
+	}
+}
+
+// releaseHold frees a seat held for a move that did not happen.
+func (s *TicketServiceServer) releaseHold(ctx context.Context, t *trip, st *seat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st.held = 0
+	if st.free() {
+		s.feed.publish(&model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number, Available: true})
+	}
+	s.serveWaitlist(ctx, t)
+}
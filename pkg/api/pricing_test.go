@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakePayments records payments and declines them while decline is set. If
+// during is set, it is called while a payment is being made.
+type fakePayments struct {
+	charges, refunds []Payment
+	decline          bool
+	during           func()
+}
+
+func (f *fakePayments) Charge(ctx context.Context, p Payment) (string, error) {
+	if f.during != nil {
+		f.during()
+	}
+	if f.decline {
+		return "", errors.New("card declined")
+	}
+	f.charges = append(f.charges, p)
+	return "charge", nil
+}
+
+func (f *fakePayments) Refund(ctx context.Context, p Payment) (string, error) {
+	f.refunds = append(f.refunds, p)
+	return "refund", nil
+}
+
+func TestChangeClassUpgradeAndDowngrade(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments))
+	res := purchaseForTest(server)
+	assert.Equal(t, model.TravelClass_TRAVEL_CLASS_STANDARD, res.TravelClass)
+	assert.Equal(t, float32(20), res.PricePaid)
+
+	up, err := server.ChangeClass(ownerOf(server, res.TicketNumber), &model.ChangeClassRequest{
+		TicketNumber:    res.TicketNumber,
+		TravelClass:     model.TravelClass_TRAVEL_CLASS_FIRST,
+		ExpectedVersion: res.Version,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(15), up.FareDifference)
+	assert.Equal(t, "charge", up.PaymentId)
+	assert.Equal(t, "2A", up.Ticket.SeatNumber)
+	assert.Equal(t, float32(35), up.Ticket.PricePaid)
+	assert.Equal(t, model.TravelClass_TRAVEL_CLASS_FIRST, up.Ticket.TravelClass)
+	assert.Equal(t, []Payment{{UserID: "U1", Amount: 15, Reference: "ticket 1 class change at version 1"}}, payments.charges)
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, float32(35), receipt.Ticket.PricePaid)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_CLASS_CHANGED, receipt.Ticket.History[1].Type)
+	assert.Equal(t, "standard 1A -> first 2A, fare difference +15.00", receipt.Ticket.History[1].Detail)
+
+	_, err = server.ChangeClass(ownerOf(server, res.TicketNumber), &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	down, err := server.ChangeClass(ownerOf(server, res.TicketNumber), &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_STANDARD})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(-15), down.FareDifference)
+	assert.Equal(t, "refund", down.PaymentId)
+	assert.Equal(t, float32(20), down.Ticket.PricePaid)
+	assert.Len(t, payments.refunds, 1)
+	assert.Equal(t, 9, server.allTrips()[0].freeSeats("A"))
+	assert.Equal(t, 10, server.allTrips()[0].freeSeats("B"))
+}
+
+func TestChangeClassNeedsOwnerOrStaff(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments), WithStaffKey("secret"))
+	res := purchaseForTest(server)
+	req := &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST}
+
+	// A stranger can neither change the class nor charge the owner for it
+	_, err := server.ChangeClass(context.Background(), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.ChangeClass(asUser(server, "U2"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.ModifyUserSeat(asUser(server, "U2"), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSection: "B"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Empty(t, payments.charges)
+	assert.Equal(t, "A", receiptFor(server, res.TicketNumber).Section)
+
+	_, err = server.ChangeClass(asStaff("secret"), req)
+	assert.NoError(t, err)
+	assert.Len(t, payments.charges, 1)
+}
+
+func TestChangeClassSeatBlockedDuringPayment(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments), WithStaffKey("secret"))
+	res := purchaseForTest(server)
+
+	payments.during = func() {
+		_, err := server.BlockSeats(asStaff("secret"), &model.BlockSeatsRequest{SeatNumbers: []string{"2A"}, Reason: "broken"})
+		assert.NoError(t, err)
+	}
+	_, err := server.ChangeClass(ownerOf(server, res.TicketNumber), &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, []Payment{{UserID: "U1", Amount: 15, Reference: "ticket 1 class change at version 1 reversal"}}, payments.refunds)
+
+	receipt := receiptFor(server, res.TicketNumber)
+	assert.Equal(t, res.SeatNumber, receipt.SeatNumber)
+	assert.Equal(t, float32(20), receipt.PricePaid)
+	st := server.allTrips()[0].byNumber["2A"]
+	assert.Zero(t, st.held)
+	assert.Zero(t, st.ticket)
+}
+
+func TestChangeClassDeclinedPaymentReleasesSeat(t *testing.T) {
+	payments := &fakePayments{decline: true}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments))
+	res := purchaseForTest(server)
+
+	// The new seat is held while the payment is made
+	payments.during = func() {
+		seatMap, _ := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{})
+		for _, st := range seatMap.Seats {
+			if st.SeatNumber == "2A" {
+				assert.Equal(t, model.SeatStatus_SEAT_STATUS_HELD, st.Status)
+			}
+		}
+	}
+
+	_, err := server.ChangeClass(ownerOf(server, res.TicketNumber), &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, res.SeatNumber, receipt.Ticket.SeatNumber)
+	assert.Equal(t, float32(20), receipt.Ticket.PricePaid)
+	assert.Equal(t, res.Version, receipt.Ticket.Version)
+	assert.Equal(t, 10, server.allTrips()[0].freeSeats("B"))
+}
+
+func TestChangeClassAbortsIfTicketChangesDuringPayment(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments))
+	res := purchaseForTest(server)
+
+	payments.during = func() {
+		_, err := server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1J"})
+		assert.NoError(t, err)
+	}
+	_, err := server.ChangeClass(ownerOf(server, res.TicketNumber), &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	assert.Equal(t, []Payment{{UserID: "U1", Amount: 15, Reference: "ticket 1 class change at version 1 reversal"}}, payments.refunds)
+	assert.Equal(t, 10, server.allTrips()[0].freeSeats("B"))
+}
+
+func TestModifyUserSeatAcrossClassesSettlesFare(t *testing.T) {
+	payments := &fakePayments{}
+	server := NewTicketServiceServer(WithPaymentProcessor(payments), WithFare(model.TravelClass_TRAVEL_CLASS_FIRST, 42.5))
+	res := purchaseForTest(server)
+
+	modified, err := server.ModifyUserSeat(ownerOf(server, res.TicketNumber), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "2C"})
+	assert.NoError(t, err)
+	assert.Equal(t, float32(22.5), modified.FareDifference)
+	assert.Equal(t, int64(2), modified.Version)
+	assert.Len(t, payments.charges, 1)
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, "2C", receipt.Ticket.SeatNumber)
+	assert.Equal(t, float32(42.5), receipt.Ticket.PricePaid)
+
+	first, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		User:        &model.User{Email: "second@example.com"},
+		TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "B", first.Section)
+	assert.Equal(t, float32(42.5), first.PricePaid)
+}
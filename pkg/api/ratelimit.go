@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// retryAfterMetadataKey is the response header telling a rate limited
+	// caller how many seconds to wait before trying again.
+	retryAfterMetadataKey = "retry-after"
+
+	// forwardedForMetadataKey carries the HTTP client's address on calls made
+	// by the REST gateway.
+	forwardedForMetadataKey = "x-forwarded-for"
+
+	// rateLimiterSweepInterval bounds how often idle buckets are purged.
+	rateLimiterSweepInterval = time.Minute
+)
+
+// rateLimiter is a set of token buckets keyed by caller. Each bucket holds up
+// to burst tokens and refills at perMinute tokens a minute; a call spends one.
+// A nil rateLimiter allows everything.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // Tokens per second
+	burst     float64
+	now       func() time.Time
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter allowing perMinute calls a minute per key,
+// with bursts of up to burst calls, or perMinute if burst is zero. It returns
+// nil, disabling the limit, if perMinute is not positive.
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow spends a token from key's bucket. If the bucket is empty it returns
+// false and how long until a token is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely, since a new bucket
+// starts full, at most once per rateLimiterSweepInterval. Callers must hold
+// l.mu.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimiterSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// UnaryServerInterceptor rejects calls from clients over their limit.
+func (l *rateLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if ok, wait := l.allow(clientIdentity(ctx)); !ok {
+			return nil, rateLimited(ctx, wait, "too many requests from this client")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects streams opened by clients over their limit.
+func (l *rateLimiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ok, wait := l.allow(clientIdentity(ss.Context())); !ok {
+			return rateLimited(ss.Context(), wait, "too many requests from this client")
+		}
+		return handler(srv, ss)
+	}
+}
+
+// clientIdentity names the caller for rate limiting by its IP address. The
+// user ID a client sends is left out: a client could send a new one with
+// every call to get a fresh allowance.
+func clientIdentity(ctx context.Context) string {
+	return "ip:" + clientAddress(ctx)
+}
+
+// clientAddress returns the caller's IP address, or "" for calls made in
+// process. Calls relayed by the local REST gateway are attributed to the HTTP
+// client's address, which the gateway appends to x-forwarded-for; earlier
+// entries come from the client and are not trusted.
+func clientAddress(ctx context.Context) string {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.IsLoopback() {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if forwarded := md.Get(forwardedForMetadataKey); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			ip = strings.TrimSpace(hops[len(hops)-1])
+		}
+	}
+	return ip
+}
+
+// rateLimited returns a ResourceExhausted error telling the caller to retry
+// after wait, both as response metadata and as a RetryInfo detail.
+func rateLimited(ctx context.Context, wait time.Duration, msg string) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	// Outside a gRPC call, as in tests, there is no header to set
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadataKey, strconv.Itoa(seconds)))
+
+	st, err := status.New(codes.ResourceExhausted, msg).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, msg)
+	}
+	return st.Err()
+}
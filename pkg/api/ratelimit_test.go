@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter := newRateLimiter(60, 2)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.allow("a")
+	assert.True(t, ok)
+	ok, _ = limiter.allow("a")
+	assert.True(t, ok)
+	ok, wait := limiter.allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// Other keys have their own bucket
+	ok, _ = limiter.allow("b")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	ok, _ = limiter.allow("a")
+	assert.True(t, ok)
+
+	assert.Nil(t, newRateLimiter(0, 10))
+	ok, _ = (*rateLimiter)(nil).allow("a")
+	assert.True(t, ok)
+}
+
+func TestPurchaseRateLimitPerEmail(t *testing.T) {
+	server := NewTicketServiceServer(WithPurchaseRateLimit(1, 1))
+
+	req := &model.PurchaseRequest{User: &model.User{FirstName: "Alice", Email: "alice@example.com"}}
+	_, err := server.PurchaseTicket(context.Background(), req)
+	assert.NoError(t, err)
+
+	// The same passenger is limited whether named by email or user ID
+	for _, req := range []*model.PurchaseRequest{
+		{User: &model.User{Email: "ALICE@example.com"}},
+		{UserId: "U1"},
+	} {
+		_, err = server.PurchaseTicket(context.Background(), req)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		details := status.Convert(err).Details()
+		if assert.Len(t, details, 1) {
+			assert.Greater(t, details[0].(*errdetails.RetryInfo).RetryDelay.AsDuration(), time.Duration(0))
+		}
+	}
+
+	_, err = server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "bob@example.com"}})
+	assert.NoError(t, err)
+}
+
+func TestPurchaseRateLimitPerClient(t *testing.T) {
+	server := NewTicketServiceServer(WithPurchaseRateLimit(1, 1))
+	client := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 4242}})
+
+	_, err := server.PurchaseTicket(client, &model.PurchaseRequest{User: &model.User{Email: "alice@example.com"}})
+	assert.NoError(t, err)
+	// A new email does not get around the limit
+	_, err = server.PurchaseTicket(client, &model.PurchaseRequest{User: &model.User{Email: "bob@example.com"}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, "too many purchases from this client", status.Convert(err).Message())
+}
+
+func TestMaxTicketsPerTrip(t *testing.T) {
+	server := NewTicketServiceServer(WithMaxTicketsPerTrip(2))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+
+	req := &model.PurchaseRequest{User: &model.User{Email: "alice@example.com"}}
+	first, err := server.PurchaseTicket(asUser(server, "U1"), req)
+	assert.NoError(t, err)
+	_, err = server.PurchaseTicket(asUser(server, "U1"), req)
+	assert.NoError(t, err)
+	_, err = server.PurchaseTicket(asUser(server, "U1"), req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The cap is per trip, and cancelled tickets stop counting
+	_, err = server.PurchaseTicket(asUser(server, "U1"), &model.PurchaseRequest{TripId: "T2", User: req.User})
+	assert.NoError(t, err)
+	_, _ = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: first.TicketNumber})
+	_, err = server.PurchaseTicket(asUser(server, "U1"), req)
+	assert.NoError(t, err)
+}
+
+func TestMaxTicketsPerTripPerClient(t *testing.T) {
+	server := NewTicketServiceServer(WithMaxTicketsPerTrip(2), WithStaffKey("secret"))
+	client := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 4242}})
+	purchase := func(ctx context.Context, email string) error {
+		_, err := server.PurchaseTicket(ctx, &model.PurchaseRequest{User: &model.User{Email: email}})
+		return err
+	}
+
+	assert.NoError(t, purchase(client, "p1@example.com"))
+	assert.NoError(t, purchase(client, "p2@example.com"))
+	// Each passenger is new, but the client has bought its share
+	err := purchase(client, "p3@example.com")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, "this client already bought 2 tickets on trip default", status.Convert(err).Message())
+
+	// Staff selling at a ticket office are not capped
+	office := metadata.NewIncomingContext(client, metadata.Pairs(staffKeyMetadataKey, "secret"))
+	assert.NoError(t, purchase(office, "p3@example.com"))
+}
+
+func TestRateLimitIgnoresUserID(t *testing.T) {
+	server := NewTicketServiceServer(WithPurchaseRateLimit(1, 1), WithMaxTicketsPerTrip(1))
+	limiter := newRateLimiter(1, 1)
+	client := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 4242}})
+	asUserFrom := func(n int) context.Context {
+		return metadata.NewIncomingContext(client, metadata.Pairs(userTokenMetadataKey, server.userToken(fmt.Sprintf("U%d", n))))
+	}
+	handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+
+	// Acting as a new user on every call gets no fresh allowance
+	var calls, purchases int
+	for n := range 20 {
+		if _, err := limiter.UnaryServerInterceptor()(asUserFrom(n), nil, &grpc.UnaryServerInfo{}, handler); err == nil {
+			calls++
+		} else {
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		}
+		req := &model.PurchaseRequest{User: &model.User{Email: fmt.Sprintf("p%d@example.com", n)}}
+		if _, err := server.PurchaseTicket(asUserFrom(n), req); err == nil {
+			purchases++
+		} else {
+			assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		}
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, purchases)
+
+	// Nor does it get around the cap on tickets per trip
+	server = NewTicketServiceServer(WithMaxTicketsPerTrip(1))
+	_, err := server.PurchaseTicket(asUserFrom(1), &model.PurchaseRequest{User: &model.User{Email: "p1@example.com"}})
+	assert.NoError(t, err)
+	_, err = server.PurchaseTicket(asUserFrom(2), &model.PurchaseRequest{User: &model.User{Email: "p2@example.com"}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestClientIdentity(t *testing.T) {
+	remote := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 4242}}
+	loopback := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 4242}}
+
+	ctx := peer.NewContext(context.Background(), remote)
+	assert.Equal(t, "ip:203.0.113.7", clientIdentity(ctx))
+
+	// The user a client acts as is not part of its identity
+	withUser := metadata.NewIncomingContext(ctx, metadata.Pairs(userTokenMetadataKey, "U1.token"))
+	assert.Equal(t, "ip:203.0.113.7", clientIdentity(withUser))
+
+	// Only the local gateway is trusted to report the client address, and only
+	// the hop it appended itself
+	forwarded := metadata.Pairs(forwardedForMetadataKey, "198.51.100.1, 192.0.2.9")
+	assert.Equal(t, "ip:203.0.113.7", clientIdentity(metadata.NewIncomingContext(ctx, forwarded)))
+	assert.Equal(t, "ip:192.0.2.9", clientIdentity(metadata.NewIncomingContext(peer.NewContext(context.Background(), loopback), forwarded)))
+}
+
+func TestGatewayRateLimitRetryAfter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	limiter := newRateLimiter(1, 1)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(limiter.UnaryServerInterceptor()))
+	server := NewTicketServiceServer()
+	model.RegisterTicketServiceServer(grpcServer, server)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gateway, err := newGateway(ctx, lis.Addr().String())
+	assert.NoError(t, err)
+	httpServer := httptest.NewServer(gateway)
+	defer httpServer.Close()
+
+	purchases := 0
+	purchase := func(userID string) *http.Response {
+		purchases++
+		body := fmt.Sprintf(`{"from":"City A","to":"City B","user":{"email":"p%d@example.com"}}`, purchases)
+		req, _ := http.NewRequest(http.MethodPost, httpServer.URL+"/v1/tickets", strings.NewReader(body))
+		if userID != "" {
+			req.Header.Set("X-User-Token", server.userToken(userID))
+		}
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	assert.Equal(t, http.StatusOK, purchase("").StatusCode)
+	res := purchase("")
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get("Retry-After"))
+
+	// Nor does acting as another user get a fresh allowance
+	assert.Equal(t, http.StatusTooManyRequests, purchase("U9").StatusCode)
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RelocateSection implementation
+func (s *TicketServiceServer) RelocateSection(ctx context.Context, req *model.RelocateSectionRequest) (*model.RelocateSectionResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return idempotent(ctx, s.idempotency, "RelocateSection", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.RelocateSectionResponse, error) {
+		return s.relocateSection(ctx, req)
+	})
+}
+
+// relocateSection blocks a section for good and moves its tickets elsewhere
+// on the trip, including overbooked tickets still waiting for a seat in it.
+// Each passenger's tickets move together, to a run of adjacent seats if there
+// is one. Tickets stay in their travel class, whose fare they paid; those
+// that fit nowhere in it are waitlisted.
+func (s *TicketServiceServer) relocateSection(ctx context.Context, req *model.RelocateSectionRequest) (*model.RelocateSectionResponse, error) {
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+	t, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	class, exists := t.classes[req.Section]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "section not found: %s", req.Section)
+	}
+
+	// Take the section out of service first so none of its seats are offered
+	var seats []*seat
+	for _, st := range t.seats {
+		if st.section == req.Section {
+			seats = append(seats, st)
+		}
+	}
+	block := &seatBlock{
+		section:   req.Section,
+		seats:     seats,
+		reason:    "section cancelled: " + req.Reason,
+		start:     time.Now(),
+		createdBy: s.actor(ctx),
+	}
+	changes := t.addBlock(block, block.start)
+
+	// Group the section's tickets by passenger: seated ones in seat order,
+	// then overbooked ones still to be given a seat in it
+	var tickets []*model.Ticket
+	for _, st := range seats {
+		if st.ticket != 0 {
+			tickets = append(tickets, t.tickets[st.ticket])
+		}
+	}
+	tickets = append(tickets, t.unassigned(req.Section)...)
+	var groups [][]*model.Ticket
+	groupOf := make(map[string]int)
+	for _, ticket := range tickets {
+		if i, exists := groupOf[ticket.UserId]; exists && ticket.UserId != "" {
+			groups[i] = append(groups[i], ticket)
+			continue
+		}
+		groupOf[ticket.UserId] = len(groups)
+		groups = append(groups, []*model.Ticket{ticket})
+	}
+
+	res := &model.RelocateSectionResponse{Block: block.proto(t.id)}
+	var notes []Notification
+	actor := s.actor(ctx)
+	for _, group := range groups {
+		to := t.seatsTogether(len(group), class)
+		for i, ticket := range group {
+			// A group that fits nowhere together is seated one by one
+			var dest *seat
+			if to != nil {
+				dest = to[i]
+			} else if single := t.seatsTogether(1, class); single != nil {
+				dest = single[0]
+			}
+
+			relocation := &model.Relocation{
+				TicketNumber:  ticket.TicketNumber,
+				UserId:        ticket.UserId,
+				OldSeatNumber: ticket.SeatNumber,
+				OldSection:    ticket.Section,
+			}
+			from := "unassigned"
+			if st := t.byNumber[ticket.SeatNumber]; st != nil {
+				st.ticket = 0
+				from = st.number
+			}
+			note := Notification{UserID: ticket.UserId, TicketNumber: ticket.TicketNumber, TripID: t.id, OldSeat: ticket.SeatNumber}
+			if dest == nil {
+				recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_RELOCATED, actor, fmt.Sprintf("%s -> waitlist, section %s cancelled", from, req.Section))
+				ticket.SeatNumber, ticket.Section = "", ""
+				t.waitlist = append(t.waitlist, ticket.TicketNumber)
+				note.Message = fmt.Sprintf("Section %s of your train has been cancelled and no other seat is free. You are on the waitlist and will be told when you have a seat.", req.Section)
+				res.Waitlisted = append(res.Waitlisted, relocation)
+			} else {
+				recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_RELOCATED, actor, fmt.Sprintf("%s -> %s, section %s cancelled", from, dest.number, req.Section))
+				dest.ticket = ticket.TicketNumber
+				ticket.SeatNumber, ticket.Section = dest.number, dest.section
+				changes = append(changes, &model.SeatChange{TripId: t.id, Section: dest.section, SeatNumber: dest.number})
+				relocation.NewSeatNumber, relocation.NewSection = dest.number, dest.section
+				note.NewSeat = dest.number
+				note.Message = fmt.Sprintf("Section %s of your train has been cancelled. Your new seat is %s.", req.Section, dest.number)
+				res.Relocations = append(res.Relocations, relocation)
+			}
+			ticket.Version++
+			notes = append(notes, note)
+		}
+	}
+	if len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	s.notify(ctx, notes...)
+
+	return res, nil
+}
+
+// seatsTogether returns n free seats in one section of class, preferring a
+// run of adjacent seats, or nil if no section of class has room. Seats of
+// another class are never offered, since the ticket's fare would no longer
+// match. Callers must hold t.mu.
+func (t *trip) seatsTogether(n int, class model.TravelClass) []*seat {
+	var fallback []*seat
+	for _, section := range t.sections {
+		if t.classes[section] != class {
+			continue
+		}
+		var free []*seat
+		for _, st := range t.seats {
+			if st.section == section && st.free() {
+				free = append(free, st)
+			}
+		}
+		if len(free) < n {
+			continue
+		}
+		for i := 0; i+n <= len(free); i++ {
+			if free[i+n-1].index-free[i].index == n-1 {
+				return free[i : i+n]
+			}
+		}
+		if fallback == nil {
+			fallback = free[:n]
+		}
+	}
+	return fallback
+}
+
+// serveWaitlist gives free seats to waitlisted tickets, first come first
+// served within each travel class, and tells their passengers. It is called
+// whenever seats may have been freed. Callers must hold t.mu for writing.
+func (s *TicketServiceServer) serveWaitlist(ctx context.Context, t *trip) {
+	var changes []*model.SeatChange
+	var notes []Notification
+	waiting := t.waitlist[:0]
+	for _, number := range t.waitlist {
+		ticket := t.tickets[number]
+		seats := t.seatsTogether(1, ticket.TravelClass)
+		if seats == nil {
+			waiting = append(waiting, number)
+			continue
+		}
+		st := seats[0]
+		recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_ASSIGNED, "", "waitlist -> "+st.number)
+		st.ticket = ticket.TicketNumber
+		ticket.SeatNumber, ticket.Section = st.number, st.section
+		ticket.Version++
+		changes = append(changes, &model.SeatChange{TripId: t.id, Section: st.section, SeatNumber: st.number})
+		notes = append(notes, Notification{
+			UserID:       ticket.UserId,
+			TicketNumber: ticket.TicketNumber,
+			TripID:       t.id,
+			NewSeat:      st.number,
+			Message:      fmt.Sprintf("A seat has been found for you on the waitlist: %s.", st.number),
+		})
+	}
+	t.waitlist = waiting
+	if len(changes) > 0 {
+		s.feed.publish(changes...)
+	}
+	s.notify(ctx, notes...)
+}
+
+// vacate frees the seat ticket holds or, if it has none, takes it off the
+// waitlist. It returns the availability changes to publish. Callers must
+// hold t.mu for writing.
+func (t *trip) vacate(ticket *model.Ticket) []*model.SeatChange {
+	st := t.byNumber[ticket.SeatNumber]
+	if st == nil {
+		t.waitlist = slices.DeleteFunc(t.waitlist, func(n int32) bool { return n == ticket.TicketNumber })
+		return nil
+	}
+	st.ticket = 0
+	if !st.free() {
+		return nil
+	}
+	return []*model.SeatChange{{TripId: t.id, Section: st.section, SeatNumber: st.number, Available: true}}
+}
+
+// seatOrWaitlist describes where a ticket sits in its history.
+func seatOrWaitlist(seatNumber string) string {
+	if seatNumber == "" {
+		return "waitlist"
+	}
+	return seatNumber
+}
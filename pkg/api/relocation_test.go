@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeNotifier passes notifications to a channel.
+type fakeNotifier chan Notification
+
+func (f fakeNotifier) Notify(ctx context.Context, n Notification) error {
+	f <- n
+	return nil
+}
+
+func (f fakeNotifier) next(t *testing.T) Notification {
+	t.Helper()
+	select {
+	case n := <-f:
+		return n
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for notification")
+		return Notification{}
+	}
+}
+
+func TestRelocateSectionKeepsGroupsTogether(t *testing.T) {
+	notes := make(fakeNotifier, 10)
+	server := NewTicketServiceServer(WithStaffKey("secret"), WithNotifier(notes))
+	server.addTrip(newTrip("T2", []string{"C", "D"}, map[string][]string{
+		"C": {"3A", "3B", "3C", "3D"},
+		"D": {"4A", "4B", "4C", "4D", "4E", "4F"},
+	}))
+	purchaseOnTrip(server, "T2", "bob")
+	for i := 0; i < 3; i++ {
+		purchaseOnTrip(server, "T2", "alice")
+	}
+	_, _ = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 1})
+	purchaseOnTrip(server, "T2", "carol")
+
+	_, err := server.RelocateSection(context.Background(), &model.RelocateSectionRequest{TripId: "T2", Section: "C", Reason: "coach withdrawn"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Alice's three tickets get adjacent seats in standard section D
+	res, err := server.RelocateSection(asStaff("secret"), &model.RelocateSectionRequest{TripId: "T2", Section: "C", Reason: "coach withdrawn"})
+	assert.NoError(t, err)
+	assert.Equal(t, "section cancelled: coach withdrawn", res.Block.Reason)
+	assert.Empty(t, res.Waitlisted)
+	assert.Equal(t, []*model.Relocation{
+		{TicketNumber: 5, UserId: "U3", OldSeatNumber: "3A", OldSection: "C", NewSeatNumber: "4A", NewSection: "D"},
+		{TicketNumber: 2, UserId: "U2", OldSeatNumber: "3B", OldSection: "C", NewSeatNumber: "4B", NewSection: "D"},
+		{TicketNumber: 3, UserId: "U2", OldSeatNumber: "3C", OldSection: "C", NewSeatNumber: "4C", NewSection: "D"},
+		{TicketNumber: 4, UserId: "U2", OldSeatNumber: "3D", OldSection: "C", NewSeatNumber: "4D", NewSection: "D"},
+	}, res.Relocations)
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 3})
+	assert.Equal(t, "4C", receipt.Ticket.SeatNumber)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_RELOCATED, receipt.Ticket.History[1].Type)
+
+	note := notes.next(t)
+	assert.Equal(t, Notification{
+		UserID:       "U3",
+		TicketNumber: 5,
+		TripID:       "T2",
+		OldSeat:      "3A",
+		NewSeat:      "4A",
+		Message:      "Section C of your train has been cancelled. Your new seat is 4A.",
+	}, note)
+
+	// The cancelled section is no longer sold
+	dave, err := purchaseOnTrip(server, "T2", "dave")
+	assert.NoError(t, err)
+	assert.Equal(t, "4E", dave.SeatNumber)
+}
+
+func TestRelocateSectionStaysInClass(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"), WithNotifier(make(fakeNotifier, 10)))
+	standard := purchaseAs(server, "alice", "Doe")
+	first, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		User:        &model.User{Email: "bob@example.com"},
+		TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST,
+	})
+	assert.NoError(t, err)
+
+	// First class B has room, but standard fares do not cover it
+	res, err := server.RelocateSection(asStaff("secret"), &model.RelocateSectionRequest{Section: "A", Reason: "coach withdrawn"})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Relocations)
+	assert.Len(t, res.Waitlisted, 1)
+	receipt := receiptFor(server, standard.TicketNumber)
+	assert.Empty(t, receipt.SeatNumber)
+	assert.Equal(t, model.TravelClass_TRAVEL_CLASS_STANDARD, receipt.TravelClass)
+	assert.Equal(t, float32(20), receipt.PricePaid)
+
+	// A first-class seat freed later is not given to the standard ticket
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: first.TicketNumber})
+	assert.NoError(t, err)
+	assert.Empty(t, receiptFor(server, standard.TicketNumber).SeatNumber)
+}
+
+func TestRelocateSectionWaitlistsOverflow(t *testing.T) {
+	notes := make(fakeNotifier, 10)
+	server := NewTicketServiceServer(WithStaffKey("secret"), WithNotifier(notes))
+	server.addTrip(newTrip("T2", []string{"C", "D"}, map[string][]string{"C": {"3A", "3B"}, "D": {"4A"}}))
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{TripId: "T2", User: &model.User{Email: email}})
+		assert.NoError(t, err)
+	}
+
+	res, err := server.RelocateSection(asStaff("secret"), &model.RelocateSectionRequest{TripId: "T2", Section: "C", Reason: "fire damage"})
+	assert.NoError(t, err)
+	assert.Empty(t, res.Relocations)
+	assert.Len(t, res.Waitlisted, 2)
+	notes.next(t)
+	notes.next(t)
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 1})
+	assert.Empty(t, receipt.Ticket.SeatNumber)
+	manifest, _ := server.ExportManifest(asStaff("secret"), &model.ExportManifestRequest{TripId: "T2"})
+	assert.Contains(t, string(manifest.Content), "Waitlist,,1,")
+	_, err = server.SwapSeats(asStaff("secret"), &model.SwapSeatsRequest{FirstTicketNumber: 1, SecondTicketNumber: 3, StaffOverride: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Removing a waitlisted ticket drops it from the waitlist; a freed seat
+	// goes to the next ticket in line
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 1})
+	assert.NoError(t, err)
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 3})
+	assert.NoError(t, err)
+
+	note := notes.next(t)
+	assert.Equal(t, int32(2), note.TicketNumber)
+	assert.Equal(t, "4A", note.NewSeat)
+	receipt, _ = server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 2})
+	assert.Equal(t, "4A", receipt.Ticket.SeatNumber)
+	assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_ASSIGNED, receipt.Ticket.History[2].Type)
+}
+
+func TestRelocateSectionMovesOverbookedTickets(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C", "D"}, map[string][]string{"C": {"3A"}, "D": {"4A", "4B", "4C"}}))
+	_, err := server.SetOverbooking(asStaff("secret"), &model.SetOverbookingRequest{TripId: "T2", Section: "C", Percent: 100})
+	assert.NoError(t, err)
+	for _, first := range []string{"a", "b", "c", "d"} {
+		_, err := purchaseOnTrip(server, "T2", first)
+		assert.NoError(t, err)
+	}
+	overbooked, err := purchaseOnTrip(server, "T2", "e")
+	assert.NoError(t, err)
+	assert.True(t, overbooked.Overbooked)
+	assert.Equal(t, "C", overbooked.Section)
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 4})
+	assert.NoError(t, err)
+
+	// The seated ticket takes the one free seat; the overbooked one, which
+	// could otherwise only be seated in C, is waitlisted
+	res, err := server.RelocateSection(asStaff("secret"), &model.RelocateSectionRequest{TripId: "T2", Section: "C", Reason: "fire damage"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Relocations, 1)
+	assert.Equal(t, "4C", res.Relocations[0].NewSeatNumber)
+	assert.Len(t, res.Waitlisted, 1)
+	assert.Equal(t, overbooked.TicketNumber, res.Waitlisted[0].TicketNumber)
+	assert.Equal(t, "C", res.Waitlisted[0].OldSection)
+
+	receipt := receiptFor(server, overbooked.TicketNumber)
+	assert.Empty(t, receipt.Section)
+	assert.Equal(t, "unassigned -> waitlist, section C cancelled", receipt.History[1].Detail)
+	manifest, _ := server.ExportManifest(asStaff("secret"), &model.ExportManifestRequest{TripId: "T2"})
+	assert.NotContains(t, string(manifest.Content), "Unassigned")
+	_, err = server.CheckIn(ownerOf(server, overbooked.TicketNumber), &model.CheckInRequest{TicketNumber: overbooked.TicketNumber})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// It is seated from the waitlist once a seat frees up
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "4A", receiptFor(server, overbooked.TicketNumber).SeatNumber)
+}
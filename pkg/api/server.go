@@ -1,22 +1,273 @@
 package api
 
 import (
-	"log"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
+	"net/textproto"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+const (
+	grpcAddr    = ":50051"
+	gatewayAddr = ":8080"
+
+	// idempotencyTTLEnv overrides how long idempotent responses are kept,
+	// as a Go duration such as "1h".
+	idempotencyTTLEnv = "IDEMPOTENCY_TTL"
+
+	// staffKeyEnv is the key staff send as x-staff-key metadata. Staff calls
+	// are refused if it is unset.
+	staffKeyEnv = "STAFF_API_KEY"
+
+	// boardingPassKeyEnv is the base64 encoded 32-byte Ed25519 seed boarding
+	// passes are signed with. If unset a key is generated at startup.
+	boardingPassKeyEnv = "BOARDING_PASS_KEY"
+
+	// userTokenKeyEnv is a base64 encoded key of at least 32 bytes that user
+	// tokens are signed with. If unset a key is generated at startup.
+	userTokenKeyEnv = "USER_TOKEN_KEY"
+
+	// defaultTripDepartureEnv is when the default trip leaves, in RFC 3339
+	// format. No-shows cannot be processed for it until this is set.
+	defaultTripDepartureEnv = "DEFAULT_TRIP_DEPARTURE"
+
+	// overbookingEnv is the percentage of extra tickets sections sell beyond
+	// their seats until staff set their own. Zero or unset disables it.
+	overbookingEnv = "OVERBOOKING_PERCENT"
+
+	// Abuse protection settings, overriding the defaults below. Zero
+	// disables a limit.
+	clientRateLimitEnv   = "RATE_LIMIT_PER_MINUTE"          // Calls per client identity or IP
+	clientRateBurstEnv   = "RATE_LIMIT_BURST"               // Calls a client may make at once
+	purchaseRateLimitEnv = "PURCHASE_RATE_LIMIT_PER_MINUTE" // Purchases per passenger email
+	maxTicketsPerTripEnv = "MAX_TICKETS_PER_TRIP"           // Active tickets per passenger per trip
+
+	defaultClientRateLimit   = 600
+	defaultClientRateBurst   = 100
+	defaultPurchaseRateLimit = 10
+	defaultMaxTicketsPerTrip = 4
+
+	// readinessInterval is how often storage readiness is re-checked for the
+	// health service.
+	readinessInterval = 5 * time.Second
 )
 
 func StartServer() {
-	lis, err := net.Listen("tcp", ":50051")
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := setupTracing(ctx)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		fatal("failed to set up tracing", err)
 	}
-	grpcServer := grpc.NewServer()
-	model.RegisterTicketServiceServer(grpcServer, NewTicketServiceServer())
-	log.Println("Server is running on port 50051...")
+
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		fatal("failed to listen", err)
+	}
+	rpcMetrics := newRPCMetrics()
+	clientLimiter := newRateLimiter(envInt(clientRateLimitEnv, defaultClientRateLimit), envInt(clientRateBurstEnv, defaultClientRateBurst))
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(rpcMetrics.UnaryServerInterceptor(), unaryLoggingInterceptor(logger), clientLimiter.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(rpcMetrics.StreamServerInterceptor(), streamLoggingInterceptor(logger), clientLimiter.StreamServerInterceptor()),
+	)
+	opts := []Option{
+		WithPurchaseRateLimit(envInt(purchaseRateLimitEnv, defaultPurchaseRateLimit), 0),
+		WithMaxTicketsPerTrip(envInt(maxTicketsPerTripEnv, defaultMaxTicketsPerTrip)),
+		WithStaffKey(os.Getenv(staffKeyEnv)),
+		WithOverbooking(envInt(overbookingEnv, 0)),
+	}
+	if seed := os.Getenv(boardingPassKeyEnv); seed != "" {
+		b, err := base64.StdEncoding.DecodeString(seed)
+		if err != nil || len(b) != ed25519.SeedSize {
+			fatal("invalid "+boardingPassKeyEnv, fmt.Errorf("want %d base64 encoded bytes", ed25519.SeedSize))
+		}
+		opts = append(opts, WithBoardingPassKey(ed25519.NewKeyFromSeed(b)))
+	}
+	if key := os.Getenv(userTokenKeyEnv); key != "" {
+		b, err := base64.StdEncoding.DecodeString(key)
+		if err != nil || len(b) < 32 {
+			fatal("invalid "+userTokenKeyEnv, fmt.Errorf("want at least 32 base64 encoded bytes"))
+		}
+		opts = append(opts, WithUserTokenKey(b))
+	}
+	if departs := os.Getenv(defaultTripDepartureEnv); departs != "" {
+		at, err := time.Parse(time.RFC3339, departs)
+		if err != nil {
+			fatal("invalid "+defaultTripDepartureEnv, err)
+		}
+		opts = append(opts, WithDefaultTripDeparture(at))
+	}
+	if ttl := os.Getenv(idempotencyTTLEnv); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			fatal("invalid "+idempotencyTTLEnv, err)
+		}
+		opts = append(opts, WithIdempotencyTTL(d))
+	}
+	ticketService := NewTicketServiceServer(opts...)
+	model.RegisterTicketServiceServer(grpcServer, ticketService)
+	model.RegisterUserServiceServer(grpcServer, NewUserServiceServer(ticketService))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	reflection.Register(grpcServer)
+
+	go watchReadiness(ctx, healthServer, ticketService)
+
+	gateway, err := newGateway(ctx, "localhost"+grpcAddr)
+	if err != nil {
+		fatal("failed to create gateway", err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(rpcMetrics, ticketService)
+
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	httpMux.Handle("/", gateway)
+	httpServer := &http.Server{Addr: gatewayAddr, Handler: httpMux}
+	go func() {
+		logger.Info("REST gateway and /metrics are running", "addr", gatewayAddr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("failed to serve gateway", err)
+		}
+	}()
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		logger.Info("shutting down")
+		// Report NOT_SERVING first so load balancers and probes stop routing
+		// new traffic here while in-flight calls drain.
+		healthServer.Shutdown()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+		grpcServer.GracefulStop()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}()
+
+	logger.Info("server is running", "addr", grpcAddr)
 	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		fatal("failed to serve", err)
+	}
+	// Serve returns as soon as GracefulStop is called; wait for the final
+	// spans to be flushed before the process exits
+	<-shutdownDone
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// envInt returns the integer in the environment variable name, or def if it
+// is unset.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		fatal("invalid "+name, err)
+	}
+	return n
+}
+
+// watchReadiness keeps the health status of every service in step with the
+// readiness of the ticket store until ctx is done.
+func watchReadiness(ctx context.Context, healthServer *health.Server, ticketService *TicketServiceServer) {
+	ticker := time.NewTicker(readinessInterval)
+	defer ticker.Stop()
+	for {
+		setServingStatus(healthServer, ticketService.Ready())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setServingStatus reports SERVING for the server and each registered
+// service when storage is ready, and NOT_SERVING otherwise.
+func setServingStatus(healthServer *health.Server, storageErr error) {
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if storageErr != nil {
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for _, service := range []string{"", model.TicketService_ServiceDesc.ServiceName, model.UserService_ServiceDesc.ServiceName} {
+		healthServer.SetServingStatus(service, servingStatus)
+	}
+}
+
+// newGateway returns an HTTP handler translating REST/JSON calls into gRPC
+// calls against the server listening on grpcEndpoint.
+func newGateway(ctx context.Context, grpcEndpoint string) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+	)
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+	if err := model.RegisterTicketServiceHandlerFromEndpoint(ctx, mux, grpcEndpoint, opts); err != nil {
+		return nil, err
+	}
+	if err := model.RegisterUserServiceHandlerFromEndpoint(ctx, mux, grpcEndpoint, opts); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// gatewayHeaderMatcher forwards the user token, staff key and
+// Idempotency-Key headers to gRPC metadata in addition to the gateway's
+// default headers. Both credentials are checked by the service.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	for _, forwarded := range []string{userTokenMetadataKey, staffKeyMetadataKey, idempotencyKeyMetadataKey} {
+		if textproto.CanonicalMIMEHeaderKey(key) == textproto.CanonicalMIMEHeaderKey(forwarded) {
+			return forwarded, true
+		}
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// gatewayOutgoingHeaderMatcher returns retry-after metadata as the standard
+// Retry-After HTTP header, and other metadata under the gateway's default
+// Grpc-Metadata- prefix.
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if key == retryAfterMetadataKey {
+		return "Retry-After", true
 	}
+	return runtime.MetadataHeaderPrefix + key, true
 }
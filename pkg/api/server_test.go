@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGatewayPurchaseAndReceipt(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	ticketService := NewTicketServiceServer()
+	model.RegisterTicketServiceServer(grpcServer, ticketService)
+	model.RegisterUserServiceServer(grpcServer, NewUserServiceServer(ticketService))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gateway, err := newGateway(ctx, lis.Addr().String())
+	assert.NoError(t, err)
+	httpServer := httptest.NewServer(gateway)
+	defer httpServer.Close()
+
+	body := `{"from":"City A","to":"City B","user":{"firstName":"Alice","lastName":"Doe","email":"alice@example.com"}}`
+	res, err := http.Post(httpServer.URL+"/v1/tickets", "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var purchase struct {
+		TicketNumber int32  `json:"ticketNumber"`
+		SeatNumber   string `json:"seatNumber"`
+		UserToken    string `json:"userToken"`
+	}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&purchase))
+	assert.Equal(t, int32(1), purchase.TicketNumber)
+	assert.Equal(t, "1A", purchase.SeatNumber)
+
+	// A retry with the same Idempotency-Key header replays the purchase
+	retry := func() int32 {
+		req, _ := http.NewRequest(http.MethodPost, httpServer.URL+"/v1/tickets", strings.NewReader(`{"from":"City A","to":"City B","user":{"email":"bob@example.com"}}`))
+		req.Header.Set("Idempotency-Key", "k1")
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		var retried struct {
+			TicketNumber int32 `json:"ticketNumber"`
+		}
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&retried))
+		return retried.TicketNumber
+	}
+	assert.Equal(t, int32(2), retry())
+	assert.Equal(t, int32(2), retry())
+
+	// The gateway forwards the user token, and a bare user ID proves nothing
+	req, _ := http.NewRequest(http.MethodGet, httpServer.URL+"/v1/me/tickets", nil)
+	req.Header.Set("X-User-Id", "U1")
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodGet, httpServer.URL+"/v1/me/tickets", nil)
+	req.Header.Set("X-User-Token", purchase.UserToken)
+	res, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var mine struct {
+		Tickets []struct {
+			TicketNumber int32 `json:"ticketNumber"`
+		} `json:"tickets"`
+	}
+	assert.NoError(t, json.NewDecoder(res.Body).Decode(&mine))
+	assert.Len(t, mine.Tickets, 1)
+
+	// Profiles are only shown to their user
+	res, err = http.Get(httpServer.URL + "/v1/users/U1")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestHealthFollowsStorageReadiness(t *testing.T) {
+	healthServer := health.NewServer()
+	service := model.TicketService_ServiceDesc.ServiceName
+
+	setServingStatus(healthServer, NewTicketServiceServer().Ready())
+	res, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+
+	setServingStatus(healthServer, (&TicketServiceServer{}).Ready())
+	res, _ = healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+
+	setServingStatus(healthServer, nil)
+	healthServer.Shutdown()
+	res, _ = healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// swapConsentTTL is how long a consent token stays valid.
+const swapConsentTTL = 15 * time.Minute
+
+// swapConsent is what an owner agrees to: trading the seat of Ticket, as it
+// is at Version, for the seat of With.
+type swapConsent struct {
+	Ticket  int32 `json:"t"`
+	With    int32 `json:"w"`
+	Version int64 `json:"v"`
+	Expires int64 `json:"e"` // Unix seconds
+}
+
+func newConsentKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// signConsent encodes c as a token that only this server can have issued.
+func (s *TicketServiceServer) signConsent(c swapConsent) string {
+	payload, _ := json.Marshal(c)
+	mac := hmac.New(sha256.New, s.consentKey)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkConsent verifies that token is the owner's consent to trade ticket's
+// seat for the seat of with, given since ticket last changed. Callers must
+// hold the lock of the ticket's trip.
+func (s *TicketServiceServer) checkConsent(token string, ticket *model.Ticket, with int32) error {
+	invalid := status.Errorf(codes.PermissionDenied, "missing or invalid consent for ticket %d", ticket.TicketNumber)
+	payload, sig, found := strings.Cut(token, ".")
+	if !found {
+		return invalid
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return invalid
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return invalid
+	}
+	mac := hmac.New(sha256.New, s.consentKey)
+	mac.Write(data)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return invalid
+	}
+
+	var c swapConsent
+	if err := json.Unmarshal(data, &c); err != nil || c.Ticket != ticket.TicketNumber || c.With != with {
+		return invalid
+	}
+	if time.Now().Unix() >= c.Expires {
+		return status.Errorf(codes.FailedPrecondition, "consent for ticket %d has expired", ticket.TicketNumber)
+	}
+	if c.Version != ticket.Version {
+		return status.Errorf(codes.Aborted, "ticket %d has changed since its owner consented", ticket.TicketNumber)
+	}
+	return nil
+}
+
+// ConsentToSwap implementation
+func (s *TicketServiceServer) ConsentToSwap(ctx context.Context, req *model.ConsentToSwapRequest) (*model.ConsentToSwapResponse, error) {
+	userID, err := s.userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.TicketNumber == req.WithTicketNumber {
+		return nil, status.Error(codes.InvalidArgument, "a ticket cannot be swapped with itself")
+	}
+
+	_, ticket, unlock := s.lockTicket(req.TicketNumber, false)
+	if ticket == nil {
+		return nil, status.Errorf(codes.NotFound, "ticket not found: %d", req.TicketNumber)
+	}
+	defer unlock()
+	if ticket.UserId != userID {
+		return nil, status.Errorf(codes.PermissionDenied, "ticket %d does not belong to %s", req.TicketNumber, userID)
+	}
+
+	expires := time.Now().Add(swapConsentTTL)
+	token := s.signConsent(swapConsent{
+		Ticket:  ticket.TicketNumber,
+		With:    req.WithTicketNumber,
+		Version: ticket.Version,
+		Expires: expires.Unix(),
+	})
+
+	return &model.ConsentToSwapResponse{
+		ConsentToken: token,
+		ExpiresAt:    timestamppb.New(time.Unix(expires.Unix(), 0)),
+	}, nil
+}
+
+// SwapSeats implementation
+func (s *TicketServiceServer) SwapSeats(ctx context.Context, req *model.SwapSeatsRequest) (*model.SwapSeatsResponse, error) {
+	return idempotent(ctx, s.idempotency, "SwapSeats", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.SwapSeatsResponse, error) {
+		return s.swapSeats(ctx, req)
+	})
+}
+
+func (s *TicketServiceServer) swapSeats(ctx context.Context, req *model.SwapSeatsRequest) (*model.SwapSeatsResponse, error) {
+	if req.FirstTicketNumber == req.SecondTicketNumber {
+		return nil, status.Error(codes.InvalidArgument, "a ticket cannot be swapped with itself")
+	}
+	if req.StaffOverride {
+		if err := s.requireStaff(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	s.ticketsMu.RLock()
+	trip, secondTrip := s.ticketTrips[req.FirstTicketNumber], s.ticketTrips[req.SecondTicketNumber]
+	s.ticketsMu.RUnlock()
+	if trip == nil || secondTrip == nil {
+		return nil, status.Error(codes.NotFound, "both tickets must exist")
+	}
+	if trip != secondTrip {
+		return nil, status.Error(codes.FailedPrecondition, "tickets are on different trips")
+	}
+	trip.mu.Lock()
+	defer trip.mu.Unlock()
+
+	first, second := trip.tickets[req.FirstTicketNumber], trip.tickets[req.SecondTicketNumber]
+	if first == nil || second == nil {
+		return nil, status.Error(codes.NotFound, "both tickets must exist")
+	}
+	now := time.Now()
+	for _, ticket := range []*model.Ticket{first, second} {
+		if err := checkChangeable(ticket); err != nil {
+			return nil, err
+		}
+		if ticket.SeatNumber == "" {
+			return nil, status.Errorf(codes.FailedPrecondition, "ticket %d has no seat", ticket.TicketNumber)
+		}
+		if trip.byNumber[ticket.SeatNumber].blocked(now) {
+			return nil, status.Errorf(codes.FailedPrecondition, "seat %s is blocked", ticket.SeatNumber)
+		}
+	}
+	if first.TravelClass != second.TravelClass {
+		return nil, status.Error(codes.FailedPrecondition, "tickets are in different travel classes; use ChangeClass")
+	}
+	if !req.StaffOverride {
+		if err := s.checkConsent(req.FirstConsentToken, first, second.TicketNumber); err != nil {
+			return nil, err
+		}
+		if err := s.checkConsent(req.SecondConsentToken, second, first.TicketNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	actor := s.actor(ctx)
+	recordEvent(first, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_SWAPPED, actor, fmt.Sprintf("%s -> %s with ticket %d", first.SeatNumber, second.SeatNumber, second.TicketNumber))
+	recordEvent(second, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_SWAPPED, actor, fmt.Sprintf("%s -> %s with ticket %d", second.SeatNumber, first.SeatNumber, first.TicketNumber))
+	trip.byNumber[first.SeatNumber].ticket = second.TicketNumber
+	trip.byNumber[second.SeatNumber].ticket = first.TicketNumber
+	first.SeatNumber, second.SeatNumber = second.SeatNumber, first.SeatNumber
+	first.Section, second.Section = second.Section, first.Section
+	first.Version++
+	second.Version++
+
+	return &model.SwapSeatsResponse{
+		Message: "Seats swapped successfully.",
+		First:   s.withUser(first),
+		Second:  s.withUser(second),
+	}, nil
+}
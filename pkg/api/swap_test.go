@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// asUser returns a context carrying the user token of userID.
+func asUser(server *TicketServiceServer, userID string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(userTokenMetadataKey, server.userToken(userID)))
+}
+
+func TestSwapSeatsWithConsent(t *testing.T) {
+	server := NewTicketServiceServer()
+	alice, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "alice@example.com"}})
+	bob, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "bob@example.com"}})
+
+	// Only the owner can consent
+	_, err := server.ConsentToSwap(asUser(server, "U2"), &model.ConsentToSwapRequest{TicketNumber: alice.TicketNumber, WithTicketNumber: bob.TicketNumber})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	aliceConsent, err := server.ConsentToSwap(asUser(server, "U1"), &model.ConsentToSwapRequest{TicketNumber: alice.TicketNumber, WithTicketNumber: bob.TicketNumber})
+	assert.NoError(t, err)
+	bobConsent, err := server.ConsentToSwap(asUser(server, "U2"), &model.ConsentToSwapRequest{TicketNumber: bob.TicketNumber, WithTicketNumber: alice.TicketNumber})
+	assert.NoError(t, err)
+
+	req := &model.SwapSeatsRequest{FirstTicketNumber: alice.TicketNumber, SecondTicketNumber: bob.TicketNumber}
+	_, err = server.SwapSeats(context.Background(), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Consent is for one particular pair of tickets
+	req.FirstConsentToken, req.SecondConsentToken = bobConsent.ConsentToken, aliceConsent.ConsentToken
+	_, err = server.SwapSeats(context.Background(), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	req.FirstConsentToken, req.SecondConsentToken = aliceConsent.ConsentToken, bobConsent.ConsentToken
+	res, err := server.SwapSeats(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "1B", res.First.SeatNumber)
+	assert.Equal(t, "1A", res.Second.SeatNumber)
+	assert.Equal(t, int64(2), res.First.Version)
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: alice.TicketNumber})
+	assert.Equal(t, "1B", receipt.Ticket.SeatNumber)
+	if assert.Len(t, receipt.Ticket.History, 2) {
+		assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_PURCHASED, receipt.Ticket.History[0].Type)
+		assert.Equal(t, "U1", receipt.Ticket.History[0].Actor)
+		assert.Equal(t, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_SWAPPED, receipt.Ticket.History[1].Type)
+		assert.Equal(t, "1A -> 1B with ticket 2", receipt.Ticket.History[1].Detail)
+	}
+
+	seatMap, _ := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{})
+	server.mu.RLock()
+	trip := server.trips[defaultTripID]
+	server.mu.RUnlock()
+	assert.Equal(t, bob.TicketNumber, trip.byNumber["1A"].ticket)
+	assert.Equal(t, model.SeatStatus_SEAT_STATUS_SOLD, seatMap.Seats[0].Status)
+
+	// The tickets have changed, so the same consent cannot be replayed
+	_, err = server.SwapSeats(context.Background(), req)
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestConsentToSwapNeedsUserToken(t *testing.T) {
+	server := NewTicketServiceServer()
+	alice, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "alice@example.com"}})
+	bob, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "bob@example.com"}})
+	req := &model.ConsentToSwapRequest{TicketNumber: alice.TicketNumber, WithTicketNumber: bob.TicketNumber}
+
+	_, err := server.ConsentToSwap(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Naming the owner is not enough to consent for them
+	spoofed := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-user-id", "U1"))
+	_, err = server.ConsentToSwap(spoofed, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = server.ConsentToSwap(asUser(server, "U2"), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = server.ConsentToSwap(asUser(server, "U1"), req)
+	assert.NoError(t, err)
+}
+
+func TestSwapSeatsStaffOverride(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	first, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "alice@example.com"}})
+	second, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "bob@example.com"}})
+	other, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{TripId: "T2", User: &model.User{Email: "carol@example.com"}})
+
+	req := &model.SwapSeatsRequest{FirstTicketNumber: first.TicketNumber, SecondTicketNumber: second.TicketNumber, StaffOverride: true}
+	wrongKey := metadata.NewIncomingContext(context.Background(), metadata.Pairs(staffKeyMetadataKey, "guess"))
+	_, err := server.SwapSeats(wrongKey, req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	staff := metadata.NewIncomingContext(context.Background(), metadata.Pairs(staffKeyMetadataKey, "secret"))
+	res, err := server.SwapSeats(staff, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "1B", res.First.SeatNumber)
+	assert.Equal(t, staffActor, res.First.History[len(res.First.History)-1].Actor)
+
+	_, err = server.SwapSeats(staff, &model.SwapSeatsRequest{FirstTicketNumber: first.TicketNumber, SecondTicketNumber: other.TicketNumber, StaffOverride: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
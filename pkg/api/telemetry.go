@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	serviceName = "trainticket"
+
+	// tracesExporterEnv selects where spans are sent: "otlp" for a collector
+	// (configured through the standard OTEL_EXPORTER_OTLP_* variables),
+	// "stdout" for pretty-printed spans, or "none" (the default).
+	tracesExporterEnv = "OTEL_TRACES_EXPORTER"
+)
+
+var tracer = otel.Tracer("github.com/amankumarcs/trainticket/pkg/api")
+
+// setupTracing installs the global tracer provider and propagator and returns
+// a function that flushes and stops the exporter.
+func setupTracing(ctx context.Context) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch name := os.Getenv(tracesExporterEnv); name {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracegrpc.New(ctx)
+	case "stdout", "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown %s %q", tracesExporterEnv, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// ticketNumbered is implemented by every request and response message that
+// carries a ticket number.
+type ticketNumbered interface {
+	GetTicketNumber() int32
+}
+
+// requestLogAttrs returns the structured fields logged for a finished call.
+func requestLogAttrs(ctx context.Context, method string, start time.Time, err error, msgs ...any) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	for _, msg := range msgs {
+		if m, ok := msg.(ticketNumbered); ok && m.GetTicketNumber() != 0 {
+			attrs = append(attrs, slog.Int("ticket_number", int(m.GetTicketNumber())))
+			break
+		}
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	return attrs
+}
+
+func logLevel(err error) slog.Level {
+	if err != nil {
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// unaryLoggingInterceptor logs one structured line per unary call.
+func unaryLoggingInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.LogAttrs(ctx, logLevel(err), "request", requestLogAttrs(ctx, info.FullMethod, start, err, req, resp)...)
+		return resp, err
+	}
+}
+
+// streamLoggingInterceptor logs one structured line per streaming call when
+// the stream ends.
+func streamLoggingInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		ctx := ss.Context()
+		logger.LogAttrs(ctx, logLevel(err), "request", requestLogAttrs(ctx, info.FullMethod, start, err)...)
+		return err
+	}
+}
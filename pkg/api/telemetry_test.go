@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+func TestPurchaseTicketSpansAndLog(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	interceptor := unaryLoggingInterceptor(logger)
+	server := NewTicketServiceServer()
+
+	ctx, rootSpan := provider.Tracer("test").Start(context.Background(), "rpc")
+	info := &grpc.UnaryServerInfo{FullMethod: model.TicketService_PurchaseTicket_FullMethodName}
+	_, err := interceptor(ctx, &model.PurchaseRequest{
+		From: "City A",
+		To:   "City B",
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	}, info, func(ctx context.Context, req any) (any, error) {
+		return server.PurchaseTicket(ctx, req.(*model.PurchaseRequest))
+	})
+	rootSpan.End()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	assert.ElementsMatch(t, []string{"allocateSeat", "storeTicket", "rpc"}, names)
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, model.TicketService_PurchaseTicket_FullMethodName, entry["method"])
+	assert.Equal(t, "OK", entry["code"])
+	assert.Equal(t, rootSpan.SpanContext().TraceID().String(), entry["trace_id"])
+	assert.Equal(t, float64(1), entry["ticket_number"])
+}
@@ -2,116 +2,316 @@ package api
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
+	"maps"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// TicketServiceServer sells seats on trips. Each trip has its own lock, so
+// requests for different trips never wait on each other and reads share their
+// trip's lock. Locks are always taken in this order: mu, trip locks in the
+// order the trips were added, usersMu, ticketsMu, then the feed's lock. The
+// payment ledger's lock is never held while taking another.
 type TicketServiceServer struct {
 	model.UnimplementedTicketServiceServer
-	mu             sync.Mutex
-	tickets        map[int32]*model.Ticket // Store tickets in memory
-	availableSeats map[string][]string     // Available seats for each section
-	sections       [2]string
+	mu          sync.RWMutex     // Guards trips and tripOrder
+	trips       map[string]*trip // Seat inventory and tickets keyed by trip ID
+	tripOrder   []string         // Trip IDs in the order they were added
+	ticketsMu   sync.RWMutex
+	ticketTrips map[int32]*trip // Trip of each live or exchanged ticket, keyed by ticket number
+	lastTicket  atomic.Int32    // Last ticket number issued
+	usersMu     sync.RWMutex
+	users       map[string]*model.User // Registered user profiles keyed by user ID
+	nextUserID  int
+	metrics     *salesMetrics
+	feed        *availabilityFeed
+	idempotency *idempotencyStore
+
+	purchaseLimiter   *rateLimiter // Purchases per passenger email; nil for no limit
+	maxTicketsPerTrip int          // Active tickets per passenger per trip; 0 for no cap
+	staffKey          string       // Authorizes staff calls; empty if there are none
+	consentKey        []byte       // Signs seat swap consent tokens
+	fares             map[model.TravelClass]float32
+	changeFee         float32          // Charged for exchanging a ticket
+	payments          PaymentProcessor // Settles fare differences
+	ledgerMu          sync.Mutex
+	ledger            []*model.PaymentRecord // Payments settled, oldest first
+	notifier          Notifier         // Tells passengers of seat changes
+	overbooking       int32            // Overbooking percentage of sections not set by staff
+	boardingPassKey   ed25519.PrivateKey
+	userTokenKey      []byte // Signs user tokens
+}
+
+// Option configures a TicketServiceServer.
+type Option func(*TicketServiceServer)
+
+// WithIdempotencyTTL sets how long responses are kept for replaying retried
+// calls that carry an idempotency key.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(s *TicketServiceServer) { s.idempotency.ttl = ttl }
+}
+
+// WithIdempotencyMaxEntries sets how many idempotency keys are remembered at
+// once. The oldest responses are forgotten to make room for new keys.
+func WithIdempotencyMaxEntries(n int) Option {
+	return func(s *TicketServiceServer) { s.idempotency.maxEntries = n }
+}
+
+// WithPurchaseRateLimit limits how many purchases a minute may be made for
+// one passenger email, and by one client, with bursts of up to burst
+// purchases. A perMinute of zero disables the limit.
+func WithPurchaseRateLimit(perMinute, burst int) Option {
+	return func(s *TicketServiceServer) { s.purchaseLimiter = newRateLimiter(perMinute, burst) }
+}
+
+// WithMaxTicketsPerTrip caps the active tickets one passenger may hold on a
+// trip, and that one client may buy for it. Zero disables the cap.
+func WithMaxTicketsPerTrip(n int) Option {
+	return func(s *TicketServiceServer) { s.maxTicketsPerTrip = n }
 }
 
 // Constructor for TicketServiceServer
-func NewTicketServiceServer() *TicketServiceServer {
-	return &TicketServiceServer{
-		tickets: make(map[int32]*model.Ticket),
-		availableSeats: map[string][]string{
-			"A": {"1A", "1B", "1C", "1D", "1E", "1F", "1G", "1H", "1I", "1J"},
-			"B": {"2A", "2B", "2C", "2D", "2E", "2F", "2G", "2H", "2I", "2J"},
-		},
-		sections: [2]string{"A", "B"},
+func NewTicketServiceServer(opts ...Option) *TicketServiceServer {
+	s := &TicketServiceServer{
+		trips:       make(map[string]*trip),
+		ticketTrips: make(map[int32]*trip),
+		users:       make(map[string]*model.User),
+		metrics:     newSalesMetrics(),
+		feed:        newAvailabilityFeed(),
+		idempotency: newIdempotencyStore(defaultIdempotencyTTL),
+		consentKey:  newConsentKey(),
+		fares:       maps.Clone(defaultFares),
+		changeFee:   defaultChangeFee,
+		payments:    &approvingPayments{},
+		notifier:    logNotifier{},
+
+		boardingPassKey: newBoardingPassKey(),
+		userTokenKey:    newUserTokenKey(),
 	}
+	s.addTrip(newDefaultTrip())
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Ready reports whether the ticket store can serve requests. The store is
+// in memory, so it is ready as soon as it has been constructed.
+func (s *TicketServiceServer) Ready() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.trips == nil || s.ticketTrips == nil {
+		return fmt.Errorf("ticket store not initialized")
+	}
+	return nil
 }
 
 // PurchaseTicket implementation
 func (s *TicketServiceServer) PurchaseTicket(ctx context.Context, req *model.PurchaseRequest) (*model.PurchaseResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return idempotent(ctx, s.idempotency, "PurchaseTicket", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.PurchaseResponse, error) {
+		return s.purchaseTicket(ctx, req)
+	})
+}
+
+func (s *TicketServiceServer) purchaseTicket(ctx context.Context, req *model.PurchaseRequest) (*model.PurchaseResponse, error) {
+	trip, err := s.trip(req.TripId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPurchaseRate(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.UserId == "" && req.User == nil {
+		return nil, status.Error(codes.InvalidArgument, "user or user_id is required")
+	}
+	trip.mu.Lock()
+	defer trip.mu.Unlock()
 
-	var section string
+	_, allocSpan := tracer.Start(ctx, "allocateSeat", trace.WithAttributes(attribute.String("trip_id", trip.id)))
+	var seat *seat
 
-	for _, v := range s.sections {
-		if len(s.availableSeats[v]) == 0 {
-			continue
-		} else {
-			section = v
-			break
+	if req.TravelClass == model.TravelClass_TRAVEL_CLASS_UNSPECIFIED {
+		for _, v := range trip.sections {
+			if seat = trip.freeSeat(v); seat != nil {
+				break
+			}
 		}
+		if seat == nil {
+			err = fmt.Errorf("no available seats in any of sections")
+		}
+	} else {
+		seat, err = trip.pick(seatChoice{class: req.TravelClass})
+	}
+	var section, seatNumber string
+	if err == nil {
+		section, seatNumber = seat.section, seat.number
+	} else if section = s.overbookSection(trip, req.TravelClass); section == "" {
+		allocSpan.SetStatus(otelcodes.Error, "sold out")
+		allocSpan.End()
+		return nil, err
+	}
+	class := trip.classes[section]
+	price, err := s.fare(class)
+	if err != nil {
+		allocSpan.End()
+		return nil, err
+	}
+	allocSpan.SetAttributes(attribute.String("section", section), attribute.String("seat_number", seatNumber))
+	allocSpan.End()
+
+	// The passenger is resolved only once a seat is found, so a failed
+	// purchase never leaves a profile behind
+	userID, registered, err := s.resolvePurchaser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if s.maxTicketsPerTrip > 0 && trip.ticketsHeldBy(userID) >= s.maxTicketsPerTrip {
+		return nil, status.Errorf(codes.ResourceExhausted, "passenger %s already holds %d tickets on trip %s", userID, s.maxTicketsPerTrip, trip.id)
 	}
-	if section == "" {
-		return nil, fmt.Errorf("no available seats in any of sections")
+	// Otherwise new emails would get around the cap
+	buyer := purchasingClient(ctx)
+	if s.maxTicketsPerTrip > 0 && buyer != "" && !s.isStaff(ctx) && trip.ticketsBoughtBy(buyer) >= s.maxTicketsPerTrip {
+		return nil, status.Errorf(codes.ResourceExhausted, "this client already bought %d tickets on trip %s", s.maxTicketsPerTrip, trip.id)
 	}
-	seat := s.availableSeats[section][0]
-	s.availableSeats[section] = s.availableSeats[section][1:]
 
-	ticket_number := int32(len(s.tickets) + 1)
+	ticket_number := s.lastTicket.Add(1)
 	ticket := &model.Ticket{
-		From:         req.From,
-		To:           req.To,
-		User:         req.User,
-		PricePaid:    20.0,
-		SeatNumber:   seat,
-		Section:      section,
-		TicketNumber: ticket_number,
+		From:            req.From,
+		To:              req.To,
+		PricePaid:       price,
+		SeatNumber:      seatNumber,
+		Section:         section,
+		TravelClass:     class,
+		TicketNumber:    ticket_number,
+		UserId:          userID,
+		TripId:          trip.id,
+		AssistanceNeeds: req.AssistanceNeeds,
+		Version:         1,
+		Overbooked:      seat == nil,
+		Status:          model.TicketStatus_TICKET_STATUS_BOOKED,
+	}
+	actor := s.actor(ctx)
+	if actor == "" {
+		actor = userID
+	}
+	message := "Ticket purchased successfully!"
+	if seat != nil {
+		recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_PURCHASED, actor, "seat "+seat.number)
+		seat.ticket = ticket_number
+		s.feed.publish(&model.SeatChange{TripId: trip.id, Section: section, SeatNumber: seat.number})
+	} else {
+		recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_PURCHASED, actor, "overbooked, section "+section)
+		message = "Ticket purchased successfully! Your seat will be assigned at check-in."
 	}
 
 	// Store ticket in memory
-	s.tickets[ticket_number] = ticket
+	_, storeSpan := tracer.Start(ctx, "storeTicket", trace.WithAttributes(attribute.Int("ticket_number", int(ticket_number))))
+	trip.tickets[ticket_number] = ticket
+	if buyer != "" {
+		trip.buyers[ticket_number] = buyer
+	}
+	s.ticketsMu.Lock()
+	s.ticketTrips[ticket_number] = trip
+	s.ticketsMu.Unlock()
+	storeSpan.End()
+	s.metrics.ticketsSold.Inc()
+	s.metrics.revenue.Add(float64(ticket.PricePaid))
 
-	return &model.PurchaseResponse{
+	res := &model.PurchaseResponse{
 		TicketNumber: ticket_number,
-		SeatNumber:   seat,
+		SeatNumber:   seatNumber,
 		Section:      section,
-		Message:      "Ticket purchased successfully!",
-	}, nil
+		Message:      message,
+		TripId:       trip.id,
+		Version:      ticket.Version,
+		PricePaid:    ticket.PricePaid,
+		TravelClass:  class,
+		Overbooked:   ticket.Overbooked,
+	}
+	if registered {
+		res.UserToken = s.userToken(userID)
+	}
+	return res, nil
 }
 
 // GetReceipt implementation
 func (s *TicketServiceServer) GetReceipt(ctx context.Context, req *model.GetReceiptRequest) (*model.GetReceiptResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	ticket, exists := s.tickets[req.TicketNumber]
-	if !exists {
+	ticket, unlock := s.lockReceipt(req.TicketNumber)
+	if ticket == nil {
 		return nil, fmt.Errorf("ticket not found for user: %d", req.TicketNumber)
 	}
+	defer unlock()
 
 	return &model.GetReceiptResponse{
-		Ticket: ticket,
+		Ticket: s.withUser(ticket),
 	}, nil
 }
 
 // ViewUsersBySection implementation
 func (s *TicketServiceServer) ViewUsersBySection(ctx context.Context, req *model.ViewUsersBySectionRequest) (*model.ViewUsersBySectionResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	var tickets []*model.Ticket
-	for _, ticket := range s.tickets {
-		if ticket.Section == req.Section {
-			tickets = append(tickets, ticket)
+	// Searching by email or name finds a given passenger, so it is kept to
+	// staff like the manifest.
+	if req.Email != "" || req.NamePrefix != "" {
+		if err := s.requireStaff(ctx); err != nil {
+			return nil, err
 		}
 	}
+	tickets, nextPageToken, err := s.listTickets(req)
+	if err != nil {
+		return nil, err
+	}
 
 	return &model.ViewUsersBySectionResponse{
-		Tickets: tickets,
+		Tickets:       tickets,
+		NextPageToken: nextPageToken,
 	}, nil
 }
 
 // RemoveUser implementation
 func (s *TicketServiceServer) RemoveUser(ctx context.Context, req *model.RemoveUserRequest) (*model.RemoveUserResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return idempotent(ctx, s.idempotency, "RemoveUser", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.RemoveUserResponse, error) {
+		return s.removeUser(ctx, req)
+	})
+}
 
-	ticket, exists := s.tickets[req.TicketNumber]
-	if exists {
-		s.availableSeats[ticket.Section] = append(s.availableSeats[ticket.Section], ticket.SeatNumber)
-		delete(s.tickets, req.TicketNumber)
+func (s *TicketServiceServer) removeUser(ctx context.Context, req *model.RemoveUserRequest) (*model.RemoveUserResponse, error) {
+	trip, ticket, unlock := s.lockTicket(req.TicketNumber, true)
+	if ticket != nil {
+		defer unlock()
+		if err := checkVersion(ticket, req.ExpectedVersion); err != nil {
+			return nil, err
+		}
+		if err := checkTransition(ticket, model.TicketStatus_TICKET_STATUS_CANCELLED); err != nil {
+			return nil, err
+		}
+		// The ticket is kept as a receipt
+		_, storeSpan := tracer.Start(ctx, "cancelTicket", trace.WithAttributes(attribute.Int("ticket_number", int(req.TicketNumber))))
+		changes := trip.vacate(ticket)
+		setStatus(ticket, model.TicketStatus_TICKET_STATUS_CANCELLED, s.actor(ctx), "")
+		ticket.Version++
+		delete(trip.tickets, req.TicketNumber)
+		trip.receipts[req.TicketNumber] = ticket
+		storeSpan.End()
+		if len(changes) > 0 {
+			s.feed.publish(changes...)
+		}
+		s.serveWaitlist(ctx, trip)
+		s.metrics.cancellations.Inc()
 		return &model.RemoveUserResponse{Message: "User removed successfully."}, nil
 	}
 	return &model.RemoveUserResponse{Message: "User not found."}, nil
@@ -119,29 +319,244 @@ func (s *TicketServiceServer) RemoveUser(ctx context.Context, req *model.RemoveU
 
 // ModifyUserSeat implementation
 func (s *TicketServiceServer) ModifyUserSeat(ctx context.Context, req *model.ModifySeatRequest) (*model.ModifySeatResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return idempotent(ctx, s.idempotency, "ModifyUserSeat", idempotencyKey(ctx, req.IdempotencyKey), req, func() (*model.ModifySeatResponse, error) {
+		return s.modifyUserSeat(ctx, req)
+	})
+}
 
-	ticket, exists := s.tickets[req.TicketNumber]
-	if !exists {
+func (s *TicketServiceServer) modifyUserSeat(ctx context.Context, req *model.ModifySeatRequest) (*model.ModifySeatResponse, error) {
+	trip, ticket, unlock := s.lockTicket(req.TicketNumber, true)
+	if ticket == nil {
 		return nil, fmt.Errorf("ticket not found for ticket: %d", req.TicketNumber)
 	}
+	defer func() { unlock() }() // unlock is replaced once the lock is handed over
+	if err := checkVersion(ticket, req.ExpectedVersion); err != nil {
+		return nil, err
+	}
+	if err := checkChangeable(ticket); err != nil {
+		return nil, err
+	}
+
+	// Use the requested seat if one is named, else the first free seat in the
+	// new section
+	to := seatChoice{seat: req.NewSeatNumber, section: req.NewSection}
+	newSeat, err := trip.pick(to)
+	if err != nil {
+		return nil, err
+	}
 
-	// Check if the new section has available seats
-	if len(s.availableSeats[req.NewSection]) == 0 {
-		return nil, fmt.Errorf("no available seats in section %s", req.NewSection)
+	// Moving to another travel class settles the fare difference first
+	if trip.classes[newSeat.section] != ticket.TravelClass {
+		unlock()
+		unlock = func() {}
+		change, err := s.changeClass(ctx, req.TicketNumber, to, req.ExpectedVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &model.ModifySeatResponse{
+			Message:        "User seat modified successfully.",
+			Version:        change.ticket.Version,
+			FareDifference: change.fareDifference,
+		}, nil
 	}
 
+	_, allocSpan := tracer.Start(ctx, "allocateSeat", trace.WithAttributes(attribute.String("trip_id", trip.id)))
 	// Add the old seat back to available seats
-	s.availableSeats[ticket.Section] = append(s.availableSeats[ticket.Section], ticket.SeatNumber)
+	changes := trip.vacate(ticket)
 
 	// Allocate the new seat
-	newSeat := s.availableSeats[req.NewSection][0]
-	s.availableSeats[req.NewSection] = s.availableSeats[req.NewSection][1:]
+	newSeat.ticket = ticket.TicketNumber
+	allocSpan.SetAttributes(attribute.String("section", newSeat.section), attribute.String("seat_number", newSeat.number))
+	allocSpan.End()
+	s.feed.publish(append(changes, &model.SeatChange{TripId: trip.id, Section: newSeat.section, SeatNumber: newSeat.number})...)
 
 	// Update the ticket
-	ticket.SeatNumber = newSeat
-	ticket.Section = req.NewSection
+	_, storeSpan := tracer.Start(ctx, "storeTicket", trace.WithAttributes(attribute.Int("ticket_number", int(req.TicketNumber))))
+	recordEvent(ticket, model.TicketEventType_TICKET_EVENT_TYPE_SEAT_CHANGED, s.actor(ctx), seatOrWaitlist(ticket.SeatNumber)+" -> "+newSeat.number)
+	ticket.SeatNumber = newSeat.number
+	ticket.Section = newSeat.section
+	ticket.Version++
+	storeSpan.End()
+	s.serveWaitlist(ctx, trip)
+
+	return &model.ModifySeatResponse{Message: "User seat modified successfully.", Version: ticket.Version}, nil
+}
 
-	return &model.ModifySeatResponse{Message: "User seat modified successfully."}, nil
+// lockTicket finds a live ticket and locks its trip, for writing if write is
+// set and for reading otherwise. It returns a nil ticket, holding no lock, if
+// there is no such ticket; otherwise the caller must call unlock.
+func (s *TicketServiceServer) lockTicket(number int32, write bool) (*trip, *model.Ticket, func()) {
+	s.ticketsMu.RLock()
+	t := s.ticketTrips[number]
+	s.ticketsMu.RUnlock()
+	if t == nil {
+		return nil, nil, nil
+	}
+
+	// Tickets never move between trips, but this one may have been removed
+	// before the lock was taken.
+	unlock := lockTrips([]*trip{t}, write)
+	ticket, exists := t.tickets[number]
+	if !exists {
+		unlock()
+		return nil, nil, nil
+	}
+	return t, ticket, unlock
+}
+
+// recordEvent appends an event to ticket's history. Callers must hold the
+// write lock of the ticket's trip.
+func recordEvent(ticket *model.Ticket, eventType model.TicketEventType, actor, detail string) {
+	ticket.History = append(ticket.History, &model.TicketEvent{
+		Type:   eventType,
+		At:     timestamppb.Now(),
+		Actor:  actor,
+		Detail: detail,
+	})
+}
+
+// checkVersion fails with Aborted if expected is set and the ticket has
+// changed since that version was read.
+func checkVersion(ticket *model.Ticket, expected int64) error {
+	if expected != 0 && expected != ticket.Version {
+		return status.Errorf(codes.Aborted, "ticket %d is at version %d, not %d", ticket.TicketNumber, ticket.Version, expected)
+	}
+	return nil
+}
+
+// ListMyTickets implementation
+func (s *TicketServiceServer) ListMyTickets(ctx context.Context, req *model.ListMyTicketsRequest) (*model.ListMyTicketsResponse, error) {
+	userID, err := s.userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := s.user(userID); !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", userID)
+	}
+
+	trips := s.allTrips()
+	unlock := lockTrips(trips, false)
+	defer unlock()
+
+	var tickets []*model.Ticket
+	for _, t := range trips {
+		for _, ticket := range t.tickets {
+			if ticket.UserId == userID {
+				tickets = append(tickets, s.withUser(ticket))
+			}
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].TicketNumber < tickets[j].TicketNumber })
+
+	return &model.ListMyTicketsResponse{
+		Tickets: tickets,
+	}, nil
+}
+
+// checkPurchaseRate spends one of the purchases allowed for the passenger's
+// email and one of those allowed for the client making it, so neither a new
+// email nor a new client gets around the limit.
+func (s *TicketServiceServer) checkPurchaseRate(ctx context.Context, req *model.PurchaseRequest) error {
+	if s.purchaseLimiter == nil {
+		return nil
+	}
+	if buyer := purchasingClient(ctx); buyer != "" {
+		if ok, wait := s.purchaseLimiter.allow(buyer); !ok {
+			return rateLimited(ctx, wait, "too many purchases from this client")
+		}
+	}
+	email := req.User.GetEmail()
+	if req.UserId != "" {
+		user, _ := s.user(req.UserId)
+		email = user.GetEmail()
+	}
+	if email == "" {
+		return nil
+	}
+	if ok, wait := s.purchaseLimiter.allow("email:" + strings.ToLower(email)); !ok {
+		return rateLimited(ctx, wait, "too many purchases for this passenger")
+	}
+	return nil
+}
+
+// purchasingClient names the client making a purchase for the purchase
+// limits, or returns "" for calls made in process, which are not limited.
+func purchasingClient(ctx context.Context) string {
+	if clientAddress(ctx) == "" {
+		return ""
+	}
+	return "client:" + clientIdentity(ctx)
+}
+
+// resolvePurchaser returns the ID of the profile a new ticket is booked for,
+// and whether it was registered by this purchase. A purchase names either a
+// registered user ID or inline user details; inline details are matched to
+// an existing profile by email, or registered as a new one. Only that user
+// or staff may book on an existing profile. Callers must hold the lock of
+// the trip being booked, so the profile cannot be deleted before the ticket
+// is stored.
+func (s *TicketServiceServer) resolvePurchaser(ctx context.Context, req *model.PurchaseRequest) (string, bool, error) {
+	// Most purchases are by known users, so look them up under the shared
+	// lock first.
+	s.usersMu.RLock()
+	id, found := s.findPurchaser(req)
+	s.usersMu.RUnlock()
+	if !found && req.UserId != "" {
+		return "", false, status.Errorf(codes.NotFound, "user not found: %s", req.UserId)
+	}
+	if !found {
+		s.usersMu.Lock()
+		// Another purchase may have registered the same email in the meantime
+		if id, found = s.findPurchaser(req); !found {
+			id = s.registerUser(req.User).UserId
+		}
+		s.usersMu.Unlock()
+		if !found {
+			return id, true, nil
+		}
+	}
+	if err := s.requireOwnerOrStaff(ctx, id); err != nil {
+		return "", false, err
+	}
+	return id, false, nil
+}
+
+// findPurchaser looks up the registered profile a purchase names. Callers
+// must hold s.usersMu.
+func (s *TicketServiceServer) findPurchaser(req *model.PurchaseRequest) (string, bool) {
+	if req.UserId != "" {
+		_, exists := s.users[req.UserId]
+		return req.UserId, exists
+	}
+	if req.User.Email != "" {
+		for _, user := range s.users {
+			if strings.EqualFold(user.Email, req.User.Email) {
+				return user.UserId, true
+			}
+		}
+	}
+	return "", false
+}
+
+// withUser returns a copy of ticket with the owner's current profile attached.
+// Callers must hold the lock of the ticket's trip, and not s.usersMu.
+func (s *TicketServiceServer) withUser(ticket *model.Ticket) *model.Ticket {
+	out := proto.Clone(ticket).(*model.Ticket)
+	if user, exists := s.user(ticket.UserId); exists {
+		out.User = user
+	}
+	return out
+}
+
+// user returns a copy of a registered profile.
+func (s *TicketServiceServer) user(id string) (*model.User, bool) {
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+
+	user, exists := s.users[id]
+	if !exists {
+		return nil, false
+	}
+	return proto.Clone(user).(*model.User), true
 }
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+)
+
+// newBenchmarkServer returns a server selling trips trips of two 64-seat
+// sections each.
+func newBenchmarkServer(trips int) (*TicketServiceServer, []string) {
+	server := NewTicketServiceServer()
+	ids := make([]string, trips)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("T%d", i)
+		seats := map[string][]string{}
+		for _, section := range []string{"A", "B"} {
+			for n := 1; n <= 64; n++ {
+				seats[section] = append(seats[section], fmt.Sprintf("%s%d", section, n))
+			}
+		}
+		server.addTrip(newTrip(ids[i], []string{"A", "B"}, seats))
+	}
+	return server, ids
+}
+
+// benchmarkPurchase buys and then cancels tickets from every goroutine, each
+// sticking to one trip, so the inventory never sells out.
+func benchmarkPurchase(b *testing.B, trips int) {
+	server, ids := newBenchmarkServer(trips)
+	var next atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := next.Add(1)
+		req := &model.PurchaseRequest{
+			From:   "City A",
+			To:     "City B",
+			TripId: ids[int(n)%len(ids)],
+			User:   &model.User{FirstName: "Bench", LastName: "User", Email: fmt.Sprintf("bench%d@example.com", n)},
+		}
+		for pb.Next() {
+			res, err := server.PurchaseTicket(context.Background(), req)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber})
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "purchases/s")
+}
+
+func BenchmarkPurchaseTicketParallel(b *testing.B) {
+	for _, trips := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("trips=%d", trips), func(b *testing.B) { benchmarkPurchase(b, trips) })
+	}
+}
+
+func BenchmarkGetReceiptParallel(b *testing.B) {
+	server, ids := newBenchmarkServer(4)
+	var tickets []int32
+	for _, id := range ids {
+		for i := 0; i < 100; i++ {
+			res, _ := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+				TripId: id,
+				User:   &model.User{Email: fmt.Sprintf("reader%d@example.com", i)},
+			})
+			tickets = append(tickets, res.TicketNumber)
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: tickets[i%len(tickets)]})
+			i++
+		}
+	})
+}
+
+// BenchmarkMixedParallel mixes nine receipt lookups with every purchase and
+// cancellation, across four trips.
+func BenchmarkMixedParallel(b *testing.B) {
+	server, ids := newBenchmarkServer(4)
+	var next atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := next.Add(1)
+		req := &model.PurchaseRequest{
+			TripId: ids[int(n)%len(ids)],
+			User:   &model.User{Email: fmt.Sprintf("mixed%d@example.com", n)},
+		}
+		res, _ := server.PurchaseTicket(context.Background(), req)
+		i := 0
+		for pb.Next() {
+			if i%10 == 0 {
+				server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber})
+				res, _ = server.PurchaseTicket(context.Background(), req)
+			} else {
+				server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+			}
+			i++
+		}
+	})
+}
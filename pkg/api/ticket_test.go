@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 
 	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
@@ -51,7 +52,7 @@ func TestPurchaseTicketNotAvailable(t *testing.T) {
 				Email:     "alice@example.com",
 			},
 		}
-		res, err := server.PurchaseTicket(context.Background(), req)
+		res, err := server.PurchaseTicket(asUser(server, "U1"), req)
 		if i <= 20 {
 			assert.Equal(t, "Ticket purchased successfully!", res.Message)
 			assert.Equal(t, int32(i), res.TicketNumber)
@@ -154,7 +155,7 @@ func TestModifyUserSeat(t *testing.T) {
 		NewSeatNumber: "2A",
 		NewSection:    "B",
 	}
-	modifyRes, err := server.ModifyUserSeat(context.Background(), modifyReq)
+	modifyRes, err := server.ModifyUserSeat(ownerOf(server, res.TicketNumber), modifyReq)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "User seat modified successfully.", modifyRes.Message)
@@ -165,3 +166,99 @@ func TestModifyUserSeat(t *testing.T) {
 	assert.Equal(t, "2A", receiptRes.Ticket.SeatNumber)
 	assert.Equal(t, "B", receiptRes.Ticket.Section)
 }
+
+func TestModifyUserSeatExpectedVersion(t *testing.T) {
+	server := NewTicketServiceServer()
+
+	ticketReq := &model.PurchaseRequest{
+		From: "City A",
+		To:   "City B",
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	}
+	res, _ := server.PurchaseTicket(context.Background(), ticketReq)
+	assert.Equal(t, int64(1), res.Version)
+
+	receipt, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	read := receipt.Ticket.Version
+
+	// The first agent's change wins and bumps the version
+	modifyRes, err := server.ModifyUserSeat(ownerOf(server, res.TicketNumber), &model.ModifySeatRequest{
+		TicketNumber:    res.TicketNumber,
+		NewSection:      "B",
+		ExpectedVersion: read,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, read+1, modifyRes.Version)
+
+	// The second agent read the same version and is rejected
+	_, err = server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{
+		TicketNumber:    res.TicketNumber,
+		NewSeatNumber:   "1C",
+		ExpectedVersion: read,
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	_, err = server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber, ExpectedVersion: read})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	receipt, _ = server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, "B", receipt.Ticket.Section)
+	assert.Equal(t, modifyRes.Version, receipt.Ticket.Version)
+
+	// Without an expected version the change is unconditional
+	_, err = server.ModifyUserSeat(ownerOf(server, res.TicketNumber), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSeatNumber: "1C"})
+	assert.NoError(t, err)
+
+	removeRes, err := server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber, ExpectedVersion: read + 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "User removed successfully.", removeRes.Message)
+}
+
+func TestConcurrentBookingKeepsInventoryConsistent(t *testing.T) {
+	server := NewTicketServiceServer()
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A", "3B", "3C", "3D"}}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tripID := []string{defaultTripID, "T2"}[i%2]
+			for j := 0; j < 20; j++ {
+				res, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+					From:   "City A",
+					To:     "City B",
+					TripId: tripID,
+					User:   &model.User{FirstName: "P", LastName: fmt.Sprint(i), Email: fmt.Sprintf("p%d@example.com", i)},
+				})
+				if err != nil {
+					continue
+				}
+				switch j % 3 {
+				case 0:
+					server.RemoveUser(context.Background(), &model.RemoveUserRequest{TicketNumber: res.TicketNumber})
+				case 1:
+					server.ModifyUserSeat(context.Background(), &model.ModifySeatRequest{TicketNumber: res.TicketNumber, NewSection: res.Section})
+				}
+				server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+				server.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{TripId: tripID})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every occupied seat belongs to the ticket booked on it and vice versa
+	for _, trip := range server.allTrips() {
+		occupied := 0
+		for _, st := range trip.seats {
+			if st.ticket == 0 {
+				continue
+			}
+			occupied++
+			ticket := trip.tickets[st.ticket]
+			if assert.NotNil(t, ticket, "seat %s", st.number) {
+				assert.Equal(t, st.number, ticket.SeatNumber)
+			}
+		}
+		assert.Equal(t, len(trip.tickets), occupied, "trip %s", trip.id)
+	}
+}
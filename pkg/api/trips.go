@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxSectionSeats bounds the seats of a section created with CreateTrip.
+const maxSectionSeats = 200
+
+// WithDefaultTripDeparture sets when the default trip leaves. Until it is
+// set, no-shows cannot be processed for the default trip.
+func WithDefaultTripDeparture(at time.Time) Option {
+	return func(s *TicketServiceServer) { s.trips[defaultTripID].departs = at }
+}
+
+// CreateTrip implementation
+func (s *TicketServiceServer) CreateTrip(ctx context.Context, req *model.CreateTripRequest) (*model.CreateTripResponse, error) {
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	if req.TripId == "" {
+		return nil, status.Error(codes.InvalidArgument, "trip_id is required")
+	}
+	if len(req.Sections) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "a trip needs at least one section")
+	}
+
+	res := &model.CreateTripResponse{TripId: req.TripId, DepartsAt: req.DepartsAt}
+	sections := make([]string, 0, len(req.Sections))
+	seatNumbers := make(map[string][]string, len(req.Sections))
+	for _, section := range req.Sections {
+		if section.Section == "" {
+			return nil, status.Error(codes.InvalidArgument, "section name is required")
+		}
+		if _, exists := seatNumbers[section.Section]; exists {
+			return nil, status.Errorf(codes.InvalidArgument, "section %s is listed twice", section.Section)
+		}
+		if section.Seats < 1 || section.Seats > maxSectionSeats {
+			return nil, status.Errorf(codes.InvalidArgument, "section %s must have between 1 and %d seats", section.Section, maxSectionSeats)
+		}
+		section = proto.Clone(section).(*model.TripSection)
+		if section.TravelClass == model.TravelClass_TRAVEL_CLASS_UNSPECIFIED {
+			section.TravelClass = model.TravelClass_TRAVEL_CLASS_STANDARD
+		}
+		// Tickets could not be priced in a class without a fare
+		if _, err := s.fare(section.TravelClass); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "section %s: %v", section.Section, status.Convert(err).Message())
+		}
+		sections = append(sections, section.Section)
+		for i := range section.Seats {
+			seatNumbers[section.Section] = append(seatNumbers[section.Section], fmt.Sprintf("%s%d", section.Section, i+1))
+		}
+		res.Sections = append(res.Sections, section)
+	}
+
+	t := newTrip(req.TripId, sections, seatNumbers)
+	if req.DepartsAt != nil {
+		t.departs = req.DepartsAt.AsTime()
+	}
+	if len(t.byNumber) != len(t.seats) {
+		// As with sections A and A1, whose tenth and first seats are both A11
+		return nil, status.Error(codes.InvalidArgument, "section names give two seats the same number")
+	}
+	for _, section := range res.Sections {
+		t.classes[section.Section] = section.TravelClass
+	}
+	if err := s.addTrip(t); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
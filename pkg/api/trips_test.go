@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTrip(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	req := &model.CreateTripRequest{
+		TripId: "T2",
+		Sections: []*model.TripSection{
+			{Section: "C", Seats: 2},
+			{Section: "D", TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST, Seats: 1},
+		},
+	}
+	_, err := server.CreateTrip(context.Background(), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	res, err := server.CreateTrip(asStaff("secret"), req)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TravelClass_TRAVEL_CLASS_STANDARD, res.Sections[0].TravelClass)
+	_, err = server.CreateTrip(asStaff("secret"), req)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	seatMap, err := server.GetSeatMap(context.Background(), &model.GetSeatMapRequest{TripId: "T2"})
+	assert.NoError(t, err)
+	var numbers []string
+	for _, st := range seatMap.Seats {
+		numbers = append(numbers, st.SeatNumber)
+	}
+	assert.Equal(t, []string{"C1", "C2", "D1"}, numbers)
+
+	purchase, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{
+		TripId:      "T2",
+		User:        &model.User{Email: "alice@example.com"},
+		TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "D1", purchase.SeatNumber)
+	assert.Equal(t, float32(35), purchase.PricePaid)
+
+	for _, invalid := range []*model.CreateTripRequest{
+		{Sections: []*model.TripSection{{Section: "C", Seats: 1}}},
+		{TripId: "T3"},
+		{TripId: "T3", Sections: []*model.TripSection{{Section: "C", Seats: 0}}},
+		{TripId: "T3", Sections: []*model.TripSection{{Section: "C", Seats: 1}, {Section: "C", Seats: 1}}},
+		{TripId: "T3", Sections: []*model.TripSection{{Section: "A", Seats: 11}, {Section: "A1", Seats: 1}}},
+		{TripId: "T3", Sections: []*model.TripSection{{Section: "C", Seats: 1, TravelClass: model.TravelClass(9)}}},
+	} {
+		_, err = server.CreateTrip(asStaff("secret"), invalid)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
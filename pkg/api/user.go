@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UserServiceServer manages passenger profiles. Profiles live alongside the
+// tickets that reference them, so it shares the ticket server's store and locks.
+type UserServiceServer struct {
+	model.UnimplementedUserServiceServer
+	tickets *TicketServiceServer
+}
+
+// Constructor for UserServiceServer
+func NewUserServiceServer(tickets *TicketServiceServer) *UserServiceServer {
+	return &UserServiceServer{tickets: tickets}
+}
+
+// RegisterUser implementation
+func (u *UserServiceServer) RegisterUser(ctx context.Context, req *model.RegisterUserRequest) (*model.RegisterUserResponse, error) {
+	if err := validateUser(req.User); err != nil {
+		return nil, err
+	}
+
+	s := u.tickets
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, req.User.Email) {
+			return nil, status.Errorf(codes.AlreadyExists, "user already registered with email %s", req.User.Email)
+		}
+	}
+	user := s.registerUser(req.User)
+
+	return &model.RegisterUserResponse{User: proto.Clone(user).(*model.User), UserToken: s.userToken(user.UserId)}, nil
+}
+
+// GetUser implementation
+func (u *UserServiceServer) GetUser(ctx context.Context, req *model.GetUserRequest) (*model.GetUserResponse, error) {
+	if err := u.tickets.requireOwnerOrStaff(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	user, exists := u.tickets.user(req.UserId)
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", req.UserId)
+	}
+
+	return &model.GetUserResponse{User: user}, nil
+}
+
+// UpdateUser implementation
+func (u *UserServiceServer) UpdateUser(ctx context.Context, req *model.UpdateUserRequest) (*model.UpdateUserResponse, error) {
+	if err := validateUser(req.User); err != nil {
+		return nil, err
+	}
+	s := u.tickets
+	if err := s.requireOwnerOrStaff(ctx, req.User.UserId); err != nil {
+		return nil, err
+	}
+
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	user, exists := s.users[req.User.UserId]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", req.User.UserId)
+	}
+	for id, other := range s.users {
+		if id != user.UserId && strings.EqualFold(other.Email, req.User.Email) {
+			return nil, status.Errorf(codes.AlreadyExists, "user already registered with email %s", req.User.Email)
+		}
+	}
+
+	// Replace rather than mutate the stored profile so responses already handed
+	// out are never changed underneath their readers.
+	updated := &model.User{
+		UserId:    user.UserId,
+		FirstName: req.User.FirstName,
+		LastName:  req.User.LastName,
+		Email:     req.User.Email,
+	}
+	s.users[user.UserId] = updated
+
+	return &model.UpdateUserResponse{User: proto.Clone(updated).(*model.User)}, nil
+}
+
+// DeleteUser implementation
+func (u *UserServiceServer) DeleteUser(ctx context.Context, req *model.DeleteUserRequest) (*model.DeleteUserResponse, error) {
+	s := u.tickets
+	if err := s.requireOwnerOrStaff(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	// Holding every trip stops the user buying a ticket while being deleted
+	trips := s.allTrips()
+	unlock := lockTrips(trips, false)
+	defer unlock()
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	if _, exists := s.users[req.UserId]; !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", req.UserId)
+	}
+	for _, t := range trips {
+		for _, ticket := range t.tickets {
+			if ticket.UserId == req.UserId {
+				return nil, status.Errorf(codes.FailedPrecondition, "user %s still holds ticket %d", req.UserId, ticket.TicketNumber)
+			}
+		}
+	}
+	delete(s.users, req.UserId)
+
+	return &model.DeleteUserResponse{Message: "User deleted successfully."}, nil
+}
+
+// ExportUserData implementation
+func (u *UserServiceServer) ExportUserData(ctx context.Context, req *model.ExportUserDataRequest) (*model.ExportUserDataResponse, error) {
+	s := u.tickets
+	if err := s.requireOwnerOrStaff(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	trips := s.allTrips()
+	unlock := lockTrips(trips, false)
+	defer unlock()
+
+	user, exists := s.user(req.UserId)
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", req.UserId)
+	}
+
+	export := &model.UserDataExport{
+		User:       user,
+		ExportedAt: timestamppb.Now(),
+		Payments:   s.paymentsOf(req.UserId),
+	}
+	for _, t := range trips {
+		for _, tickets := range []map[int32]*model.Ticket{t.tickets, t.receipts} {
+			for _, ticket := range tickets {
+				if ticket.UserId == req.UserId {
+					export.Tickets = append(export.Tickets, s.withUser(ticket))
+				}
+			}
+		}
+	}
+	sort.Slice(export.Tickets, func(i, j int) bool { return export.Tickets[i].TicketNumber < export.Tickets[j].TicketNumber })
+
+	bundle, err := protojson.Marshal(export)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode export: %v", err)
+	}
+
+	return &model.ExportUserDataResponse{Json: string(bundle)}, nil
+}
+
+// EraseUser implementation. The profile is deleted and the personal details on
+// the user's tickets are replaced with placeholders, as is the user ID in the
+// history of every ticket they changed; ticket numbers, journeys, prices and
+// payments are kept because they are financial records.
+func (u *UserServiceServer) EraseUser(ctx context.Context, req *model.EraseUserRequest) (*model.EraseUserResponse, error) {
+	s := u.tickets
+	if err := s.requireOwnerOrStaff(ctx, req.UserId); err != nil {
+		return nil, err
+	}
+	trips := s.allTrips()
+	unlock := lockTrips(trips, true)
+	defer unlock()
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	if _, exists := s.users[req.UserId]; !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", req.UserId)
+	}
+
+	var anonymized int32
+	for _, t := range trips {
+		for _, tickets := range []map[int32]*model.Ticket{t.tickets, t.receipts} {
+			for _, ticket := range tickets {
+				changed := false
+				if ticket.UserId == req.UserId {
+					ticket.UserId = ""
+					ticket.User = &model.User{
+						FirstName: erasedPlaceholder,
+						LastName:  erasedPlaceholder,
+						Email:     erasedPlaceholder,
+					}
+					anonymized++
+					changed = true
+				}
+				for _, event := range ticket.History {
+					if event.Actor == req.UserId {
+						event.Actor = erasedPlaceholder
+						changed = true
+					}
+				}
+				if changed {
+					ticket.Version++
+				}
+			}
+		}
+	}
+	delete(s.users, req.UserId)
+	s.forgetPayer(req.UserId)
+
+	return &model.EraseUserResponse{
+		Message:           "User data erased successfully.",
+		TicketsAnonymized: anonymized,
+	}, nil
+}
+
+// IssueUserToken implementation
+func (u *UserServiceServer) IssueUserToken(ctx context.Context, req *model.IssueUserTokenRequest) (*model.IssueUserTokenResponse, error) {
+	s := u.tickets
+	if err := s.requireStaff(ctx); err != nil {
+		return nil, err
+	}
+	if _, exists := s.user(req.UserId); !exists {
+		return nil, status.Errorf(codes.NotFound, "user not found: %s", req.UserId)
+	}
+	return &model.IssueUserTokenResponse{UserToken: s.userToken(req.UserId)}, nil
+}
+
+// registerUser stores a new profile built from details and assigns it a user ID.
+// Callers must hold s.usersMu.
+func (s *TicketServiceServer) registerUser(details *model.User) *model.User {
+	s.nextUserID++
+	user := &model.User{
+		UserId:    fmt.Sprintf("U%d", s.nextUserID),
+		FirstName: details.FirstName,
+		LastName:  details.LastName,
+		Email:     details.Email,
+	}
+	s.users[user.UserId] = user
+	return user
+}
+
+// erasedPlaceholder replaces personal details on tickets of an erased user.
+const erasedPlaceholder = "[erased]"
+
+func validateUser(user *model.User) error {
+	if user == nil {
+		return status.Error(codes.InvalidArgument, "user is required")
+	}
+	if user.Email == "" {
+		return status.Error(codes.InvalidArgument, "email is required")
+	}
+	return nil
+}
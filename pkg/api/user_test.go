@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestUserProfileLifecycle(t *testing.T) {
+	server := NewTicketServiceServer()
+	users := NewUserServiceServer(server)
+
+	regRes, err := users.RegisterUser(context.Background(), &model.RegisterUserRequest{
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	})
+	assert.NoError(t, err)
+	userID := regRes.User.UserId
+	assert.NotEmpty(t, userID)
+	owner := asUser(server, userID)
+
+	_, err = users.RegisterUser(context.Background(), &model.RegisterUserRequest{
+		User: &model.User{FirstName: "Alice", Email: "ALICE@example.com"},
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	updRes, err := users.UpdateUser(owner, &model.UpdateUserRequest{
+		User: &model.User{UserId: userID, FirstName: "Alicia", LastName: "Doe", Email: "alice@example.com"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alicia", updRes.User.FirstName)
+
+	getRes, err := users.GetUser(owner, &model.GetUserRequest{UserId: userID})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alicia", getRes.User.FirstName)
+
+	delRes, err := users.DeleteUser(owner, &model.DeleteUserRequest{UserId: userID})
+	assert.NoError(t, err)
+	assert.Equal(t, "User deleted successfully.", delRes.Message)
+
+	_, err = users.GetUser(owner, &model.GetUserRequest{UserId: userID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUserProfilesArePrivate(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	users := NewUserServiceServer(server)
+	alice, _ := users.RegisterUser(context.Background(), &model.RegisterUserRequest{User: &model.User{Email: "alice@example.com"}})
+	bob, _ := users.RegisterUser(context.Background(), &model.RegisterUserRequest{User: &model.User{Email: "bob@example.com"}})
+	aliceID := alice.User.UserId
+
+	// Neither an anonymous caller nor another user may read, change or
+	// delete a profile
+	for _, ctx := range []context.Context{context.Background(), asUser(server, bob.User.UserId)} {
+		_, err := users.GetUser(ctx, &model.GetUserRequest{UserId: aliceID})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = users.UpdateUser(ctx, &model.UpdateUserRequest{User: &model.User{UserId: aliceID, Email: "mallory@example.com"}})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = users.DeleteUser(ctx, &model.DeleteUserRequest{UserId: aliceID})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	}
+	got, err := users.GetUser(asStaff("secret"), &model.GetUserRequest{UserId: aliceID})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice@example.com", got.User.Email)
+}
+
+func TestTicketReferencesUser(t *testing.T) {
+	server := NewTicketServiceServer()
+	users := NewUserServiceServer(server)
+
+	regRes, _ := users.RegisterUser(context.Background(), &model.RegisterUserRequest{
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	})
+	userID := regRes.User.UserId
+
+	_, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{From: "City A", To: "City B", UserId: userID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	// The token handed out at registration proves the caller is the user
+	owner := metadata.NewIncomingContext(context.Background(), metadata.Pairs(userTokenMetadataKey, regRes.UserToken))
+	res, err := server.PurchaseTicket(owner, &model.PurchaseRequest{From: "City A", To: "City B", UserId: userID})
+	assert.NoError(t, err)
+	assert.Empty(t, res.UserToken)
+
+	// Inline details with a known email are linked to the existing profile,
+	// but only for its owner; the refused purchase sold no seat
+	inline := &model.PurchaseRequest{
+		From: "City B",
+		To:   "City C",
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	}
+	_, err = server.PurchaseTicket(asUser(server, "U9"), inline)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	linked, err := server.PurchaseTicket(asUser(server, userID), inline)
+	assert.NoError(t, err)
+	assert.Equal(t, res.TicketNumber+1, linked.TicketNumber)
+
+	_, _ = users.UpdateUser(owner, &model.UpdateUserRequest{
+		User: &model.User{UserId: userID, FirstName: "Alicia", LastName: "Doe", Email: "alice@example.com"},
+	})
+
+	receiptRes, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.Equal(t, userID, receiptRes.Ticket.UserId)
+	assert.Equal(t, "Alicia", receiptRes.Ticket.User.FirstName)
+
+	_, err = users.DeleteUser(owner, &model.DeleteUserRequest{UserId: userID})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = server.PurchaseTicket(asUser(server, "U404"), &model.PurchaseRequest{UserId: "U404"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestUserTokens(t *testing.T) {
+	server := NewTicketServiceServer(WithStaffKey("secret"))
+	users := NewUserServiceServer(server)
+
+	// A purchase that registers a profile hands out its token
+	res, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{User: &model.User{Email: "alice@example.com"}})
+	assert.NoError(t, err)
+	owner := metadata.NewIncomingContext(context.Background(), metadata.Pairs(userTokenMetadataKey, res.UserToken))
+	_, err = server.CheckIn(owner, &model.CheckInRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+
+	// Staff can issue another copy; nobody else can
+	_, err = users.IssueUserToken(owner, &model.IssueUserTokenRequest{UserId: "U1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	issued, err := users.IssueUserToken(asStaff("secret"), &model.IssueUserTokenRequest{UserId: "U1"})
+	assert.NoError(t, err)
+	assert.Equal(t, res.UserToken, issued.UserToken)
+	_, err = users.IssueUserToken(asStaff("secret"), &model.IssueUserTokenRequest{UserId: "U404"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// A token with its user ID changed is refused
+	forged := metadata.NewIncomingContext(context.Background(), metadata.Pairs(userTokenMetadataKey, "U2"+res.UserToken[2:]))
+	_, err = server.ListMyTickets(forged, &model.ListMyTicketsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestFailedPurchaseRegistersNoUser(t *testing.T) {
+	server := NewTicketServiceServer()
+	server.addTrip(newTrip("T2", []string{"C"}, map[string][]string{"C": {"3A"}}))
+	_, err := server.PurchaseTicket(context.Background(), &model.PurchaseRequest{TripId: "T2", User: &model.User{Email: "alice@example.com"}})
+	assert.NoError(t, err)
+
+	_, err = server.PurchaseTicket(context.Background(), &model.PurchaseRequest{TripId: "T2", User: &model.User{Email: "bob@example.com"}})
+	assert.Error(t, err)
+	assert.Len(t, server.users, 1)
+}
+
+func TestListMyTickets(t *testing.T) {
+	server := NewTicketServiceServer()
+
+	for _, email := range []string{"alice@example.com", "bob@example.com", "alice@example.com"} {
+		req := &model.PurchaseRequest{
+			From: "City A",
+			To:   "City B",
+			User: &model.User{FirstName: "Test", LastName: "User", Email: email},
+		}
+		_, _ = server.PurchaseTicket(ownerContext(server, req), req)
+	}
+	receiptRes, _ := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: 1})
+	userID := receiptRes.Ticket.UserId
+
+	_, err := server.ListMyTickets(context.Background(), &model.ListMyTicketsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// A user ID alone proves nothing, nor does a token signed by another key
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-user-id", userID))
+	_, err = server.ListMyTickets(ctx, &model.ListMyTicketsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = server.ListMyTickets(asUser(NewTicketServiceServer(), userID), &model.ListMyTicketsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	res, err := server.ListMyTickets(asUser(server, userID), &model.ListMyTicketsRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, res.Tickets, 2)
+	assert.Equal(t, int32(1), res.Tickets[0].TicketNumber)
+	assert.Equal(t, int32(3), res.Tickets[1].TicketNumber)
+}
+
+func TestExportAndEraseUserData(t *testing.T) {
+	server := NewTicketServiceServer()
+	users := NewUserServiceServer(server)
+
+	regRes, _ := users.RegisterUser(context.Background(), &model.RegisterUserRequest{
+		User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+	})
+	userID := regRes.User.UserId
+	res, _ := server.PurchaseTicket(asUser(server, userID), &model.PurchaseRequest{From: "City A", To: "City B", UserId: userID})
+	_, err := server.ChangeClass(asUser(server, userID), &model.ChangeClassRequest{TicketNumber: res.TicketNumber, TravelClass: model.TravelClass_TRAVEL_CLASS_FIRST})
+	assert.NoError(t, err)
+
+	// Only the user or staff may export or erase their data
+	_, err = users.ExportUserData(context.Background(), &model.ExportUserDataRequest{UserId: userID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = users.ExportUserData(asUser(server, "U2"), &model.ExportUserDataRequest{UserId: userID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = users.EraseUser(asUser(server, "U2"), &model.EraseUserRequest{UserId: userID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	exportRes, err := users.ExportUserData(asUser(server, userID), &model.ExportUserDataRequest{UserId: userID})
+	assert.NoError(t, err)
+	var export model.UserDataExport
+	assert.NoError(t, protojson.Unmarshal([]byte(exportRes.Json), &export))
+	assert.Equal(t, "alice@example.com", export.User.Email)
+	assert.Len(t, export.Tickets, 1)
+	assert.Equal(t, res.TicketNumber, export.Tickets[0].TicketNumber)
+	assert.Len(t, export.Payments, 1)
+	assert.Equal(t, float32(15), export.Payments[0].Amount)
+	assert.Equal(t, "ticket 1 class change at version 1", export.Payments[0].Reference)
+
+	eraseRes, err := users.EraseUser(asUser(server, userID), &model.EraseUserRequest{UserId: userID})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), eraseRes.TicketsAnonymized)
+	assert.Empty(t, server.paymentsOf(userID))
+	assert.Len(t, server.ledger, 1)
+
+	_, err = users.GetUser(asUser(server, userID), &model.GetUserRequest{UserId: userID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	receiptRes, err := server.GetReceipt(context.Background(), &model.GetReceiptRequest{TicketNumber: res.TicketNumber})
+	assert.NoError(t, err)
+	assert.Empty(t, receiptRes.Ticket.UserId)
+	assert.Equal(t, erasedPlaceholder, receiptRes.Ticket.User.Email)
+	// The user ID is gone from the history too
+	assert.Equal(t, erasedPlaceholder, receiptRes.Ticket.History[0].Actor)
+	for _, event := range receiptRes.Ticket.History {
+		assert.NotEqual(t, userID, event.Actor)
+	}
+	assert.Equal(t, float32(35.0), receiptRes.Ticket.PricePaid)
+}
@@ -0,0 +1,77 @@
+// Package boardingpass signs and verifies boarding pass tokens. A token is
+// the protobuf encoding of a BoardingPass and its Ed25519 signature, each
+// base64url encoded and joined by a dot. It is small enough for a QR code
+// and can be checked offline with only the public key. A signed validation
+// bundle adds the state of the trip's tickets, so a scanner without a
+// connection can also tell whether a pass is still good for travel.
+package boardingpass
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	qrcode "github.com/skip2/go-qrcode"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrMalformed is returned for a token that is not a boarding pass.
+	ErrMalformed = errors.New("malformed boarding pass")
+	// ErrBadSignature is returned for a pass not signed by the key it is
+	// checked against.
+	ErrBadSignature = errors.New("invalid boarding pass signature")
+)
+
+// KeyID returns a short identifier of a public key: the first eight bytes
+// of its SHA-256 hash, in hex.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign stamps pass with the ID of key and returns it as a signed token.
+func Sign(key ed25519.PrivateKey, pass *model.BoardingPass) (string, error) {
+	pass.KeyId = KeyID(key.Public().(ed25519.PublicKey))
+	payload, err := proto.Marshal(pass)
+	if err != nil {
+		return "", err
+	}
+	sig := ed25519.Sign(key, payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks the signature of token against pub and returns the pass it
+// carries.
+func Verify(token string, pub ed25519.PublicKey) (*model.BoardingPass, error) {
+	encoded, encodedSig, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(pub, payload, sig) {
+		return nil, ErrBadSignature
+	}
+	pass := &model.BoardingPass{}
+	if err := proto.Unmarshal(payload, pass); err != nil {
+		return nil, ErrMalformed
+	}
+	return pass, nil
+}
+
+// QRCode renders token as a QR code PNG image size pixels square, or larger
+// if the code does not fit.
+func QRCode(token string, size int) ([]byte, error) {
+	return qrcode.Encode(token, qrcode.Medium, size)
+}
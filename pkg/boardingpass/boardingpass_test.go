@@ -0,0 +1,87 @@
+package boardingpass
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"image/png"
+	"strings"
+	"testing"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	pass := &model.BoardingPass{TicketNumber: 7, TripId: "default", SeatNumber: "1A", PassengerName: "Alice Doe"}
+	token, err := Sign(key, pass)
+	assert.NoError(t, err)
+	assert.Equal(t, KeyID(pub), pass.KeyId)
+	assert.Len(t, pass.KeyId, 16)
+
+	got, err := Verify(token, pub)
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(pass, got))
+
+	// A pass edited after signing, or signed by another key, is refused
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, err = Verify(token, other)
+	assert.ErrorIs(t, err, ErrBadSignature)
+	payload, sig, _ := strings.Cut(token, ".")
+	forged := &model.BoardingPass{TicketNumber: 7, TripId: "default", SeatNumber: "2A", PassengerName: "Alice Doe", KeyId: pass.KeyId}
+	forgedToken, _ := Sign(key, forged)
+	forgedPayload, _, _ := strings.Cut(forgedToken, ".")
+	_, err = Verify(forgedPayload+"."+sig, pub)
+	assert.ErrorIs(t, err, ErrBadSignature)
+	_, err = Verify(payload, pub)
+	assert.ErrorIs(t, err, ErrMalformed)
+	_, err = Verify("!!."+sig, pub)
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestQRCode(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	token, _ := Sign(key, &model.BoardingPass{TicketNumber: 1, TripId: "default", SeatNumber: "1A"})
+	qr, err := QRCode(token, 256)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(qr))
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, img.Bounds().Dx(), 256)
+}
+
+func TestBundle(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	bundle := &model.ValidationBundle{TripId: "default", Tickets: []*model.BundleTicket{{TicketNumber: 1, SeatNumber: "1A"}}}
+	data, sig, err := SignBundle(key, bundle)
+	assert.NoError(t, err)
+
+	got, err := OpenBundle(data, sig, pub)
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(bundle, got))
+	assert.Equal(t, KeyID(pub), got.KeyId)
+
+	// A bundle signed by another key fails even though it carries that key
+	other, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	forged, forgedSig, _ := SignBundle(otherKey, &model.ValidationBundle{TripId: "default"})
+	_, err = OpenBundle(forged, forgedSig, pub)
+	assert.ErrorIs(t, err, ErrBundleSignature)
+	// and the key a bundle carries is never trusted by itself
+	_, err = OpenBundle(forged, forgedSig, nil)
+	assert.ErrorIs(t, err, ErrNoTrustedKey)
+	_, err = OpenBundle(data, sig, nil)
+	assert.ErrorIs(t, err, ErrNoTrustedKey)
+	_, err = OpenBundle(data, sig, other)
+	assert.ErrorIs(t, err, ErrBundleSignature)
+	_, err = OpenBundle(append(data, 0), sig, pub)
+	assert.ErrorIs(t, err, ErrMalformedBundle)
+}
+
+func TestCheck(t *testing.T) {
+	pass := &model.BoardingPass{TicketNumber: 1, TripId: "default", SeatNumber: "1A"}
+	assert.Empty(t, Check(pass, "default", model.TicketStatus_TICKET_STATUS_BOARDED, "1A"))
+	assert.Equal(t, "boarding pass is for trip default", Check(pass, "T2", model.TicketStatus_TICKET_STATUS_CHECKED_IN, "1A"))
+	assert.Equal(t, "ticket is no show", Check(pass, "default", model.TicketStatus_TICKET_STATUS_NO_SHOW, "1A"))
+	assert.Equal(t, "ticket has moved to seat 2A; the boarding pass must be reissued", Check(pass, "default", model.TicketStatus_TICKET_STATUS_CHECKED_IN, "2A"))
+}
@@ -0,0 +1,67 @@
+package boardingpass
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrMalformedBundle is returned for data that is not a validation bundle.
+	ErrMalformedBundle = errors.New("malformed validation bundle")
+	// ErrBundleSignature is returned for a bundle not signed by the key it is
+	// checked against.
+	ErrBundleSignature = errors.New("invalid validation bundle signature")
+	// ErrNoTrustedKey is returned when a bundle is opened without a key to
+	// check it against.
+	ErrNoTrustedKey = errors.New("no trusted key to check the validation bundle against")
+)
+
+// SignBundle stamps bundle with the public key of key and returns its
+// encoding and signature.
+func SignBundle(key ed25519.PrivateKey, bundle *model.ValidationBundle) (data, sig []byte, err error) {
+	pub := key.Public().(ed25519.PublicKey)
+	bundle.PublicKey = pub
+	bundle.KeyId = KeyID(pub)
+	data, err = proto.MarshalOptions{Deterministic: true}.Marshal(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, ed25519.Sign(key, data), nil
+}
+
+// OpenBundle checks the signature of a bundle against trusted, the key
+// pinned from GetBoardingPassKey, and decodes it. The key the bundle carries
+// is never trusted on its own, since anyone can sign a bundle with a key of
+// their own.
+func OpenBundle(data, sig []byte, trusted ed25519.PublicKey) (*model.ValidationBundle, error) {
+	if trusted == nil {
+		return nil, ErrNoTrustedKey
+	}
+	bundle := &model.ValidationBundle{}
+	if err := proto.Unmarshal(data, bundle); err != nil || len(bundle.PublicKey) != ed25519.PublicKeySize {
+		return nil, ErrMalformedBundle
+	}
+	if !trusted.Equal(ed25519.PublicKey(bundle.PublicKey)) || !ed25519.Verify(trusted, data, sig) {
+		return nil, ErrBundleSignature
+	}
+	return bundle, nil
+}
+
+// Check returns why pass does not admit its holder to trip tripID, given
+// the current status and seat of its ticket, or "" if it does.
+func Check(pass *model.BoardingPass, tripID string, st model.TicketStatus, seatNumber string) string {
+	switch {
+	case pass.TripId != tripID:
+		return fmt.Sprintf("boarding pass is for trip %s", pass.TripId)
+	case st != model.TicketStatus_TICKET_STATUS_CHECKED_IN && st != model.TicketStatus_TICKET_STATUS_BOARDED:
+		return "ticket is " + strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(st.String(), "TICKET_STATUS_"), "_", " "))
+	case seatNumber != pass.SeatNumber:
+		return fmt.Sprintf("ticket has moved to seat %s; the boarding pass must be reissued", seatNumber)
+	}
+	return ""
+}
@@ -0,0 +1,374 @@
+// Package client is a Go SDK for the TicketService. It wraps the generated
+// gRPC client with typed errors, per-call deadlines, retries with backoff
+// when the server is unavailable, and idempotency keys so retried mutations
+// take effect at most once.
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	mathrand "math/rand/v2"
+	"time"
+
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// IdempotencyKeyMetadata is the request metadata key carrying the
+// idempotency key of a mutating call.
+const IdempotencyKeyMetadata = "idempotency-key"
+
+type options struct {
+	timeout        time.Duration
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	dialOptions    []grpc.DialOption
+	staffKey       string
+	userToken      string
+}
+
+func defaultOptions() options {
+	return options{
+		timeout:        10 * time.Second,
+		maxAttempts:    4,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     2 * time.Second,
+		dialOptions:    []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+	}
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+// WithTimeout sets the deadline applied to calls whose context has none,
+// covering every attempt. Zero disables it.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithRetry sets how many attempts a call gets while the server is
+// unavailable, and the bounds of the exponential backoff between them.
+func WithRetry(maxAttempts int, initialBackoff, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.maxAttempts = maxAttempts
+		o.initialBackoff = initialBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithDialOptions replaces the options used by New to dial the server. The
+// default is an insecure connection.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOptions = opts }
+}
+
+// WithStaffKey sends key as the x-staff-key metadata of every call, which
+// authorizes staff-only operations such as overriding seat swap consent.
+func WithStaffKey(key string) Option {
+	return func(o *options) { o.staffKey = key }
+}
+
+// WithUserToken sends token as the x-user-token metadata of every call,
+// proving which passenger the client acts for. Tokens are returned when a
+// profile is registered.
+func WithUserToken(token string) Option {
+	return func(o *options) { o.userToken = token }
+}
+
+// Client calls the TicketService.
+type Client struct {
+	conn    *grpc.ClientConn // Set when the client owns the connection
+	tickets model.TicketServiceClient
+	opts    options
+}
+
+// New connects to the TicketService at target.
+func New(target string, opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	conn, err := grpc.NewClient(target, o.dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, tickets: model.NewTicketServiceClient(conn), opts: o}, nil
+}
+
+// NewFromConn returns a client using an existing connection, which the
+// caller keeps ownership of.
+func NewFromConn(cc grpc.ClientConnInterface, opts ...Option) *Client {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Client{tickets: model.NewTicketServiceClient(cc), opts: o}
+}
+
+// Close closes the connection opened by New.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context whose mutating calls use key instead
+// of a generated one. Reuse a key to retry a call across process restarts.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// invoke runs fn, retrying while the server is unavailable. Mutating calls
+// send the same idempotency key on every attempt so the server can replay
+// the first result instead of applying the change twice.
+func invoke[Req, Res any](ctx context.Context, c *Client, method string, mutating bool, fn func(context.Context, Req, ...grpc.CallOption) (Res, error), req Req) (Res, error) {
+	var zero Res
+	if _, ok := ctx.Deadline(); !ok && c.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.timeout)
+		defer cancel()
+	}
+	if c.opts.staffKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-staff-key", c.opts.staffKey)
+	}
+	if md, _ := metadata.FromOutgoingContext(ctx); c.opts.userToken != "" && len(md.Get("x-user-token")) == 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-user-token", c.opts.userToken)
+	}
+	if mutating {
+		key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+		if key == "" {
+			key = newIdempotencyKey()
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, IdempotencyKeyMetadata, key)
+	}
+
+	attempts := max(c.opts.maxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		res, err := fn(ctx, req)
+		if err == nil {
+			return res, nil
+		}
+		if status.Code(err) != codes.Unavailable || attempt == attempts {
+			return zero, wrapError(method, err)
+		}
+		select {
+		case <-ctx.Done():
+			return zero, wrapError(method, status.FromContextError(ctx.Err()).Err())
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// backoff returns the wait before the attempt after attempt: exponential
+// growth capped at maxBackoff, with full jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := float64(c.opts.initialBackoff) * math.Pow(2, float64(attempt-1))
+	d = math.Min(d, float64(c.opts.maxBackoff))
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(mathrand.Int64N(int64(d)) + 1)
+}
+
+// PurchaseTicket buys a ticket.
+func (c *Client) PurchaseTicket(ctx context.Context, req *model.PurchaseRequest) (*model.PurchaseResponse, error) {
+	return invoke(ctx, c, "PurchaseTicket", true, c.tickets.PurchaseTicket, req)
+}
+
+// GetReceipt returns the ticket with the given number.
+func (c *Client) GetReceipt(ctx context.Context, ticketNumber int32) (*model.Ticket, error) {
+	res, err := invoke(ctx, c, "GetReceipt", false, c.tickets.GetReceipt, &model.GetReceiptRequest{TicketNumber: ticketNumber})
+	if err != nil {
+		return nil, err
+	}
+	return res.Ticket, nil
+}
+
+// ViewUsersBySection returns one page of the tickets matching req.
+func (c *Client) ViewUsersBySection(ctx context.Context, req *model.ViewUsersBySectionRequest) (*model.ViewUsersBySectionResponse, error) {
+	return invoke(ctx, c, "ViewUsersBySection", false, c.tickets.ViewUsersBySection, req)
+}
+
+// RemoveUser cancels a ticket and frees its seat. Set req.ExpectedVersion to
+// fail with ErrConflict if the ticket changed since it was read.
+func (c *Client) RemoveUser(ctx context.Context, req *model.RemoveUserRequest) (*model.RemoveUserResponse, error) {
+	return invoke(ctx, c, "RemoveUser", true, c.tickets.RemoveUser, req)
+}
+
+// ModifyUserSeat moves a ticket to another seat. Set req.ExpectedVersion to
+// fail with ErrConflict if the ticket changed since it was read.
+func (c *Client) ModifyUserSeat(ctx context.Context, req *model.ModifySeatRequest) (*model.ModifySeatResponse, error) {
+	return invoke(ctx, c, "ModifyUserSeat", true, c.tickets.ModifyUserSeat, req)
+}
+
+// ChangeClass moves a ticket to another travel class, charging or refunding
+// the fare difference.
+func (c *Client) ChangeClass(ctx context.Context, req *model.ChangeClassRequest) (*model.ChangeClassResponse, error) {
+	return invoke(ctx, c, "ChangeClass", true, c.tickets.ChangeClass, req)
+}
+
+// ExchangeTicket trades a ticket for one on another trip, charging the change
+// fee and fare difference.
+func (c *Client) ExchangeTicket(ctx context.Context, req *model.ExchangeTicketRequest) (*model.ExchangeTicketResponse, error) {
+	return invoke(ctx, c, "ExchangeTicket", true, c.tickets.ExchangeTicket, req)
+}
+
+// BlockSeats takes seats out of service. It needs a client made WithStaffKey.
+func (c *Client) BlockSeats(ctx context.Context, req *model.BlockSeatsRequest) (*model.BlockSeatsResponse, error) {
+	return invoke(ctx, c, "BlockSeats", true, c.tickets.BlockSeats, req)
+}
+
+// UnblockSeats lifts a seat block. It needs a client made WithStaffKey.
+func (c *Client) UnblockSeats(ctx context.Context, tripID, blockID string) error {
+	_, err := invoke(ctx, c, "UnblockSeats", true, c.tickets.UnblockSeats, &model.UnblockSeatsRequest{TripId: tripID, BlockId: blockID})
+	return err
+}
+
+// ListSeatBlocks returns a trip's seat blocks and the reassignments proposed
+// for tickets in blocked seats. It needs a client made WithStaffKey.
+func (c *Client) ListSeatBlocks(ctx context.Context, tripID string) (*model.ListSeatBlocksResponse, error) {
+	return invoke(ctx, c, "ListSeatBlocks", false, c.tickets.ListSeatBlocks, &model.ListSeatBlocksRequest{TripId: tripID})
+}
+
+// RelocateSection cancels a section and moves its tickets to other seats or
+// the waitlist. It needs a client made WithStaffKey.
+func (c *Client) RelocateSection(ctx context.Context, req *model.RelocateSectionRequest) (*model.RelocateSectionResponse, error) {
+	return invoke(ctx, c, "RelocateSection", true, c.tickets.RelocateSection, req)
+}
+
+// CheckIn checks a booked ticket in, assigning a seat if it has none.
+func (c *Client) CheckIn(ctx context.Context, req *model.CheckInRequest) (*model.Ticket, error) {
+	res, err := invoke(ctx, c, "CheckIn", true, c.tickets.CheckIn, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Ticket, nil
+}
+
+// Board records that the passenger of a checked-in ticket has boarded. It
+// needs a client made WithStaffKey.
+func (c *Client) Board(ctx context.Context, ticketNumber int32) (*model.Ticket, error) {
+	res, err := invoke(ctx, c, "Board", true, c.tickets.Board, &model.BoardRequest{TicketNumber: ticketNumber})
+	if err != nil {
+		return nil, err
+	}
+	return res.Ticket, nil
+}
+
+// ProcessNoShows marks a departed trip's tickets that never boarded as
+// no-shows. It needs a client made WithStaffKey.
+func (c *Client) ProcessNoShows(ctx context.Context, req *model.ProcessNoShowsRequest) (*model.ProcessNoShowsResponse, error) {
+	return invoke(ctx, c, "ProcessNoShows", true, c.tickets.ProcessNoShows, req)
+}
+
+// GetBoardingPass returns the signed boarding pass of a checked-in ticket,
+// with its QR code.
+func (c *Client) GetBoardingPass(ctx context.Context, req *model.GetBoardingPassRequest) (*model.GetBoardingPassResponse, error) {
+	return invoke(ctx, c, "GetBoardingPass", false, c.tickets.GetBoardingPass, req)
+}
+
+// VerifyBoardingPass checks a scanned boarding pass against the ticket's
+// current state. It needs a client made WithStaffKey.
+func (c *Client) VerifyBoardingPass(ctx context.Context, req *model.VerifyBoardingPassRequest) (*model.VerifyBoardingPassResponse, error) {
+	return invoke(ctx, c, "VerifyBoardingPass", false, c.tickets.VerifyBoardingPass, req)
+}
+
+// GetBoardingPassKey returns the public key that verifies boarding passes.
+func (c *Client) GetBoardingPassKey(ctx context.Context) (*model.GetBoardingPassKeyResponse, error) {
+	return invoke(ctx, c, "GetBoardingPassKey", false, c.tickets.GetBoardingPassKey, &model.GetBoardingPassKeyRequest{})
+}
+
+// ExportValidationBundle returns a signed snapshot of a trip's checked-in
+// tickets, for checking boarding passes offline with the conductor package.
+// It needs a client made WithStaffKey.
+func (c *Client) ExportValidationBundle(ctx context.Context, tripID string) (*model.ExportValidationBundleResponse, error) {
+	return invoke(ctx, c, "ExportValidationBundle", false, c.tickets.ExportValidationBundle, &model.ExportValidationBundleRequest{TripId: tripID})
+}
+
+// SyncScans uploads boarding pass scans made offline. It needs a client made
+// WithStaffKey.
+func (c *Client) SyncScans(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error) {
+	return invoke(ctx, c, "SyncScans", true, c.tickets.SyncScans, req)
+}
+
+// SetOverbooking sets how far a trip's sections may be sold beyond their
+// seats. It needs a client made WithStaffKey.
+func (c *Client) SetOverbooking(ctx context.Context, req *model.SetOverbookingRequest) ([]*model.OverbookingPolicy, error) {
+	res, err := invoke(ctx, c, "SetOverbooking", true, c.tickets.SetOverbooking, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.Policies, nil
+}
+
+// GetOverbooking returns the overbooking policy of each section of a trip.
+// It needs a client made WithStaffKey.
+func (c *Client) GetOverbooking(ctx context.Context, tripID string) ([]*model.OverbookingPolicy, error) {
+	res, err := invoke(ctx, c, "GetOverbooking", false, c.tickets.GetOverbooking, &model.GetOverbookingRequest{TripId: tripID})
+	if err != nil {
+		return nil, err
+	}
+	return res.Policies, nil
+}
+
+// OffloadSection seats a section's overbooked tickets where it can and
+// rebooks or refunds the rest. It needs a client made WithStaffKey.
+func (c *Client) OffloadSection(ctx context.Context, req *model.OffloadSectionRequest) (*model.OffloadSectionResponse, error) {
+	return invoke(ctx, c, "OffloadSection", true, c.tickets.OffloadSection, req)
+}
+
+// CreateTrip puts a new trip on sale. It needs a client made WithStaffKey.
+func (c *Client) CreateTrip(ctx context.Context, req *model.CreateTripRequest) (*model.CreateTripResponse, error) {
+	return invoke(ctx, c, "CreateTrip", false, c.tickets.CreateTrip, req)
+}
+
+// GetSeatMap returns every seat of a trip; an empty tripID means the
+// default trip.
+func (c *Client) GetSeatMap(ctx context.Context, tripID string) (*model.GetSeatMapResponse, error) {
+	return invoke(ctx, c, "GetSeatMap", false, c.tickets.GetSeatMap, &model.GetSeatMapRequest{TripId: tripID})
+}
+
+// ListMyTickets returns the tickets of the user userToken belongs to.
+func (c *Client) ListMyTickets(ctx context.Context, userToken string) ([]*model.Ticket, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user-token", userToken)
+	res, err := invoke(ctx, c, "ListMyTickets", false, c.tickets.ListMyTickets, &model.ListMyTicketsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Tickets, nil
+}
+
+// ExportManifest renders the passenger manifest of a trip. It needs a client
+// made WithStaffKey.
+func (c *Client) ExportManifest(ctx context.Context, req *model.ExportManifestRequest) (*model.ExportManifestResponse, error) {
+	return invoke(ctx, c, "ExportManifest", false, c.tickets.ExportManifest, req)
+}
+
+// ConsentToSwap returns the consent of the user userToken belongs to, who
+// owns ticketNumber, to trade seats with withTicketNumber.
+func (c *Client) ConsentToSwap(ctx context.Context, userToken string, ticketNumber, withTicketNumber int32) (*model.ConsentToSwapResponse, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user-token", userToken)
+	return invoke(ctx, c, "ConsentToSwap", false, c.tickets.ConsentToSwap, &model.ConsentToSwapRequest{TicketNumber: ticketNumber, WithTicketNumber: withTicketNumber})
+}
+
+// SwapSeats trades the seats of two tickets, with both owners' consent
+// tokens or, for a client made WithStaffKey, a staff override.
+func (c *Client) SwapSeats(ctx context.Context, req *model.SwapSeatsRequest) (*model.SwapSeatsResponse, error) {
+	return invoke(ctx, c, "SwapSeats", true, c.tickets.SwapSeats, req)
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/api"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// flakyServer fails the first failures calls with Unavailable and records
+// the idempotency key of every call it sees. With lostResponses set, the
+// failing calls still reach the server and only their responses are lost.
+type flakyServer struct {
+	mu            sync.Mutex
+	failures      int
+	lostResponses bool
+	keys          []string
+}
+
+func (f *flakyServer) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	f.mu.Lock()
+	md, _ := metadata.FromIncomingContext(ctx)
+	f.keys = append(f.keys, md.Get(IdempotencyKeyMetadata)...)
+	fail := f.failures > 0
+	f.failures--
+	f.mu.Unlock()
+	if fail && f.lostResponses {
+		handler(ctx, req)
+	}
+	if fail {
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	return handler(ctx, req)
+}
+
+func newTestClient(t *testing.T, flaky *flakyServer, opts ...Option) *Client {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := grpc.NewServer(grpc.UnaryInterceptor(flaky.intercept))
+	model.RegisterTicketServiceServer(server, api.NewTicketServiceServer())
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	opts = append([]Option{WithRetry(4, time.Millisecond, 5*time.Millisecond)}, opts...)
+	c, err := New(lis.Addr().String(), opts...)
+	assert.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+var purchaseReq = &model.PurchaseRequest{
+	From: "City A",
+	To:   "City B",
+	User: &model.User{FirstName: "Alice", LastName: "Doe", Email: "alice@example.com"},
+}
+
+func TestRetryReusesIdempotencyKey(t *testing.T) {
+	flaky := &flakyServer{failures: 2}
+	c := newTestClient(t, flaky)
+
+	res, err := c.PurchaseTicket(context.Background(), purchaseReq)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), res.TicketNumber)
+	// Booking again on the profile the purchase registered needs its token
+	c.opts.userToken = res.UserToken
+
+	assert.Len(t, flaky.keys, 3)
+	assert.NotEmpty(t, flaky.keys[0])
+	assert.Equal(t, flaky.keys[0], flaky.keys[1])
+	assert.Equal(t, flaky.keys[0], flaky.keys[2])
+
+	_, err = c.PurchaseTicket(WithIdempotencyKey(context.Background(), "my-key"), purchaseReq)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-key", flaky.keys[3])
+}
+
+func TestRetriedPurchaseDoesNotDoubleBook(t *testing.T) {
+	flaky := &flakyServer{failures: 2, lostResponses: true}
+	c := newTestClient(t, flaky)
+
+	res, err := c.PurchaseTicket(context.Background(), purchaseReq)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), res.TicketNumber)
+
+	list, err := c.ViewUsersBySection(context.Background(), &model.ViewUsersBySectionRequest{})
+	assert.NoError(t, err)
+	assert.Len(t, list.Tickets, 1)
+}
+
+func TestReadsSendNoIdempotencyKey(t *testing.T) {
+	flaky := &flakyServer{}
+	c := newTestClient(t, flaky)
+
+	_, err := c.GetSeatMap(context.Background(), "")
+	assert.NoError(t, err)
+	assert.Empty(t, flaky.keys)
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	flaky := &flakyServer{failures: 10}
+	c := newTestClient(t, flaky)
+
+	_, err := c.GetReceipt(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, 6, flaky.failures)
+}
+
+func TestTypedErrors(t *testing.T) {
+	c := newTestClient(t, &flakyServer{})
+
+	_, err := c.GetSeatMap(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	var clientErr *Error
+	assert.True(t, errors.As(err, &clientErr))
+	assert.Equal(t, codes.NotFound, clientErr.Code())
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = c.ListMyTickets(context.Background(), "U404.forged")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Zero(t, clientErr.RetryAfter())
+
+	st, _ := status.New(codes.ResourceExhausted, "slow down").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)})
+	err = wrapError("PurchaseTicket", st.Err())
+	assert.ErrorIs(t, err, ErrResourceExhausted)
+	assert.True(t, errors.As(err, &clientErr))
+	assert.Equal(t, 3*time.Second, clientErr.RetryAfter())
+}
+
+func TestDeadline(t *testing.T) {
+	flaky := &flakyServer{failures: 100}
+	c := newTestClient(t, flaky, WithRetry(100, 50*time.Millisecond, 50*time.Millisecond), WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	_, err := c.GetReceipt(context.Background(), 1)
+	assert.Error(t, err)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Less(t, time.Since(start), time.Second)
+}
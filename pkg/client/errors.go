@@ -0,0 +1,77 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Sentinel errors for the failure classes callers usually branch on. Test
+// for them with errors.Is.
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrAlreadyExists     = errors.New("already exists")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrConflict          = errors.New("conflict")
+	ErrUnauthenticated   = errors.New("unauthenticated")
+	ErrResourceExhausted = errors.New("resource exhausted")
+	ErrUnavailable       = errors.New("service unavailable")
+)
+
+// Error is a failed call. It carries the gRPC status and unwraps to the
+// matching sentinel error, if any.
+type Error struct {
+	Method string
+	Status *status.Status
+}
+
+func (e *Error) Error() string {
+	return e.Method + ": " + e.Status.Code().String() + ": " + e.Status.Message()
+}
+
+// Code returns the gRPC status code of the failure.
+func (e *Error) Code() codes.Code { return e.Status.Code() }
+
+// RetryAfter returns how long the server asked the caller to wait before
+// retrying, as it does when rate limiting, or zero if it gave no delay.
+func (e *Error) RetryAfter() time.Duration {
+	for _, detail := range e.Status.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration()
+		}
+	}
+	return 0
+}
+
+// GRPCStatus lets status.FromError and status.Code see through Error.
+func (e *Error) GRPCStatus() *status.Status { return e.Status }
+
+func (e *Error) Unwrap() error {
+	switch e.Status.Code() {
+	case codes.NotFound:
+		return ErrNotFound
+	case codes.AlreadyExists:
+		return ErrAlreadyExists
+	case codes.InvalidArgument:
+		return ErrInvalidArgument
+	case codes.Aborted, codes.FailedPrecondition:
+		return ErrConflict
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return ErrUnauthenticated
+	case codes.ResourceExhausted:
+		return ErrResourceExhausted
+	case codes.Unavailable:
+		return ErrUnavailable
+	}
+	return nil
+}
+
+func wrapError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Method: method, Status: status.Convert(err)}
+}
@@ -0,0 +1,192 @@
+// Package conductor checks boarding passes on a train that has lost its
+// connection. A Verifier holds a validation bundle exported before the train
+// left coverage, checks scanned passes against it, and queues the scans
+// until they can be synced back to the TicketService.
+package conductor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrOtherTrip is returned when a bundle for another trip is loaded.
+var ErrOtherTrip = errors.New("validation bundle is for another trip")
+
+// Verdict is the outcome of a scan.
+type Verdict struct {
+	Valid bool
+	// Why the pass was refused.
+	Reason string
+	// The pass scanned, or nil if it is not genuine.
+	Pass *model.BoardingPass
+	// Whether the ticket was already accepted by an earlier scan.
+	Rescan bool
+}
+
+// Syncer uploads queued scans. *client.Client is a Syncer.
+type Syncer interface {
+	SyncScans(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error)
+}
+
+// Verifier checks boarding passes against a validation bundle. It is safe
+// for concurrent use.
+type Verifier struct {
+	mu      sync.Mutex
+	syncing sync.Mutex // Held while a sync is in flight
+	pub     ed25519.PublicKey
+	bundle  *model.ValidationBundle
+	tickets map[int32]*model.BundleTicket
+	scanned map[int32]bool // Tickets accepted so far
+	queue   []*model.Scan  // Scans not yet synced, oldest first
+	now     func() time.Time
+}
+
+// New opens a bundle from ExportValidationBundle. The signature is checked
+// against trusted, the key pinned from GetBoardingPassKey, which is required.
+func New(bundle, signature []byte, trusted ed25519.PublicKey) (*Verifier, error) {
+	b, err := boardingpass.OpenBundle(bundle, signature, trusted)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verifier{pub: b.PublicKey, scanned: make(map[int32]bool), now: time.Now}
+	v.load(b)
+	return v, nil
+}
+
+func (v *Verifier) load(b *model.ValidationBundle) {
+	v.bundle = b
+	v.tickets = make(map[int32]*model.BundleTicket, len(b.Tickets))
+	for _, ticket := range b.Tickets {
+		v.tickets[ticket.TicketNumber] = ticket
+	}
+}
+
+// Update replaces the bundle with a newer one for the same trip, signed by
+// the same key, keeping the queue.
+func (v *Verifier) Update(bundle, signature []byte) error {
+	b, err := boardingpass.OpenBundle(bundle, signature, v.pub)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if b.TripId != v.bundle.TripId {
+		return ErrOtherTrip
+	}
+	if b.GeneratedAt >= v.bundle.GeneratedAt {
+		v.load(b)
+	}
+	return nil
+}
+
+// TripID returns the trip the bundle is for.
+func (v *Verifier) TripID() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.bundle.TripId
+}
+
+// GeneratedAt returns when the bundle was exported, to judge how stale it is.
+func (v *Verifier) GeneratedAt() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return time.Unix(v.bundle.GeneratedAt, 0)
+}
+
+// Scan checks the boarding pass token read from a QR code and queues the
+// scan for syncing. Only tickets the bundle lists are admitted. A pass
+// issued after the bundle was exported may name a seat the bundle does not
+// know yet, so its seat is taken from the pass; a ticket checked in after
+// the export is refused until a newer bundle is loaded.
+func (v *Verifier) Scan(token string) Verdict {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var verdict Verdict
+	scan := &model.Scan{Token: token, ScannedAt: timestamppb.New(v.now())}
+	pass, err := boardingpass.Verify(token, v.pub)
+	if err != nil {
+		verdict.Reason = err.Error()
+	} else {
+		verdict.Pass = pass
+		scan.TicketNumber = pass.TicketNumber
+		verdict.Reason = v.check(pass)
+	}
+	verdict.Valid = verdict.Reason == ""
+	if verdict.Valid {
+		verdict.Rescan = v.scanned[pass.TicketNumber]
+		v.scanned[pass.TicketNumber] = true
+	}
+
+	scan.Valid, scan.Reason = verdict.Valid, verdict.Reason
+	v.queue = append(v.queue, scan)
+	return verdict
+}
+
+// check returns why pass is refused, or "" if it is accepted.
+func (v *Verifier) check(pass *model.BoardingPass) string {
+	ticket := v.tickets[pass.TicketNumber]
+	newer := pass.IssuedAt > v.bundle.GeneratedAt
+	switch {
+	case pass.TripId != v.bundle.TripId:
+		return boardingpass.Check(pass, v.bundle.TripId, model.TicketStatus_TICKET_STATUS_CHECKED_IN, pass.SeatNumber)
+	case ticket == nil && newer:
+		return "ticket is not in the validation bundle; load a newer bundle"
+	case ticket == nil:
+		return "ticket is not valid for travel"
+	case newer:
+		return boardingpass.Check(pass, v.bundle.TripId, ticket.Status, pass.SeatNumber)
+	}
+	return boardingpass.Check(pass, v.bundle.TripId, ticket.Status, ticket.SeatNumber)
+}
+
+// Pending returns the scans not yet synced, oldest first. Save them if the
+// scanner may restart before it can sync, and Requeue them after.
+func (v *Verifier) Pending() []*model.Scan {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]*model.Scan(nil), v.queue...)
+}
+
+// Requeue adds scans saved from Pending back to the queue.
+func (v *Verifier) Requeue(scans ...*model.Scan) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, scan := range scans {
+		if scan.Valid {
+			v.scanned[scan.TicketNumber] = true
+		}
+	}
+	v.queue = append(v.queue, scans...)
+}
+
+// Sync uploads the queued scans and drops them from the queue once the
+// service has them. Scans made while the upload is in flight stay queued.
+// Syncing the same scans twice is harmless.
+func (v *Verifier) Sync(ctx context.Context, syncer Syncer) (*model.SyncScansResponse, error) {
+	v.syncing.Lock()
+	defer v.syncing.Unlock()
+	v.mu.Lock()
+	scans := append([]*model.Scan(nil), v.queue...)
+	tripID := v.bundle.TripId
+	v.mu.Unlock()
+	if len(scans) == 0 {
+		return &model.SyncScansResponse{}, nil
+	}
+
+	res, err := syncer.SyncScans(ctx, &model.SyncScansRequest{TripId: tripID, Scans: scans})
+	if err != nil {
+		return nil, err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.queue = v.queue[len(scans):]
+	return res, nil
+}
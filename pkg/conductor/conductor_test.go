@@ -0,0 +1,114 @@
+package conductor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amankumarcs/trainticket/pkg/boardingpass"
+	model "github.com/amankumarcs/trainticket/pkg/model/ticketing"
+	"github.com/stretchr/testify/assert"
+)
+
+type syncerFunc func(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error)
+
+func (f syncerFunc) SyncScans(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error) {
+	return f(ctx, req)
+}
+
+func TestScan(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	data, sig, _ := boardingpass.SignBundle(key, &model.ValidationBundle{
+		TripId:      "default",
+		GeneratedAt: 100,
+		Tickets: []*model.BundleTicket{
+			{TicketNumber: 1, SeatNumber: "1A", Status: model.TicketStatus_TICKET_STATUS_CHECKED_IN},
+			{TicketNumber: 2, SeatNumber: "1C", Status: model.TicketStatus_TICKET_STATUS_CHECKED_IN},
+		},
+	})
+	v, err := New(data, sig, pub)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", v.TripID())
+	assert.Equal(t, time.Unix(100, 0), v.GeneratedAt())
+
+	pass := func(n int32, seat string, issuedAt int64) string {
+		token, _ := boardingpass.Sign(key, &model.BoardingPass{TicketNumber: n, TripId: "default", SeatNumber: seat, IssuedAt: issuedAt})
+		return token
+	}
+	verdict := v.Scan(pass(1, "1A", 90))
+	assert.True(t, verdict.Valid)
+	assert.False(t, verdict.Rescan)
+	assert.True(t, v.Scan(pass(1, "1A", 90)).Rescan)
+	assert.Equal(t, "ticket has moved to seat 1C; the boarding pass must be reissued", v.Scan(pass(2, "1B", 90)).Reason)
+	assert.Equal(t, "ticket is not valid for travel", v.Scan(pass(3, "1D", 90)).Reason)
+	// A pass issued after the export may move a listed ticket, but cannot
+	// admit a ticket the bundle does not list
+	assert.True(t, v.Scan(pass(2, "1D", 110)).Valid)
+	assert.Equal(t, "ticket is not in the validation bundle; load a newer bundle", v.Scan(pass(3, "1D", 110)).Reason)
+
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	forged, _ := boardingpass.Sign(otherKey, &model.BoardingPass{TicketNumber: 1, TripId: "default", SeatNumber: "1A"})
+	verdict = v.Scan(forged)
+	assert.False(t, verdict.Valid)
+	assert.Nil(t, verdict.Pass)
+	assert.Equal(t, "invalid boarding pass signature", verdict.Reason)
+
+	// A bundle signed by another key cannot replace this one
+	otherData, otherSig, _ := boardingpass.SignBundle(otherKey, &model.ValidationBundle{TripId: "default", GeneratedAt: 200})
+	assert.ErrorIs(t, v.Update(otherData, otherSig), boardingpass.ErrBundleSignature)
+	otherTrip, otherTripSig, _ := boardingpass.SignBundle(key, &model.ValidationBundle{TripId: "T2", GeneratedAt: 200})
+	assert.ErrorIs(t, v.Update(otherTrip, otherTripSig), ErrOtherTrip)
+	newer, newerSig, _ := boardingpass.SignBundle(key, &model.ValidationBundle{TripId: "default", GeneratedAt: 200})
+	assert.NoError(t, v.Update(newer, newerSig))
+	assert.Equal(t, "ticket is not valid for travel", v.Scan(pass(1, "1A", 90)).Reason)
+	assert.Len(t, v.Pending(), 8)
+
+	// A bundle is only opened against a pinned key
+	forgedBundle, forgedSig, _ := boardingpass.SignBundle(otherKey, &model.ValidationBundle{TripId: "default", GeneratedAt: 300})
+	_, err = New(forgedBundle, forgedSig, nil)
+	assert.ErrorIs(t, err, boardingpass.ErrNoTrustedKey)
+	_, err = New(forgedBundle, forgedSig, pub)
+	assert.ErrorIs(t, err, boardingpass.ErrBundleSignature)
+}
+
+func TestSync(t *testing.T) {
+	pub, key, _ := ed25519.GenerateKey(rand.Reader)
+	data, sig, _ := boardingpass.SignBundle(key, &model.ValidationBundle{
+		TripId:  "T2",
+		Tickets: []*model.BundleTicket{{TicketNumber: 1, SeatNumber: "1A", Status: model.TicketStatus_TICKET_STATUS_CHECKED_IN}},
+	})
+	v, _ := New(data, sig, pub)
+	token, _ := boardingpass.Sign(key, &model.BoardingPass{TicketNumber: 1, TripId: "T2", SeatNumber: "1A"})
+	v.Scan(token)
+	v.Scan("garbage")
+
+	// Scans stay queued until the service has them
+	offline := syncerFunc(func(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error) {
+		return nil, errors.New("unavailable")
+	})
+	_, err := v.Sync(context.Background(), offline)
+	assert.Error(t, err)
+	saved := v.Pending()
+	assert.Len(t, saved, 2)
+	assert.Equal(t, int32(1), saved[0].TicketNumber)
+	assert.True(t, saved[0].Valid)
+	assert.Equal(t, "malformed boarding pass", saved[1].Reason)
+
+	// After a restart the saved scans are requeued
+	restarted, _ := New(data, sig, pub)
+	restarted.Requeue(saved...)
+	assert.True(t, restarted.Scan(token).Rescan)
+	var synced *model.SyncScansRequest
+	online := syncerFunc(func(ctx context.Context, req *model.SyncScansRequest) (*model.SyncScansResponse, error) {
+		synced = req
+		return &model.SyncScansResponse{}, nil
+	})
+	_, err = restarted.Sync(context.Background(), online)
+	assert.NoError(t, err)
+	assert.Equal(t, "T2", synced.TripId)
+	assert.Len(t, synced.Scans, 3)
+	assert.Empty(t, restarted.Pending())
+}
@@ -19,11 +19,35 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TicketService_PurchaseTicket_FullMethodName     = "/model.TicketService/PurchaseTicket"
-	TicketService_GetReceipt_FullMethodName         = "/model.TicketService/GetReceipt"
-	TicketService_ViewUsersBySection_FullMethodName = "/model.TicketService/ViewUsersBySection"
-	TicketService_RemoveUser_FullMethodName         = "/model.TicketService/RemoveUser"
-	TicketService_ModifyUserSeat_FullMethodName     = "/model.TicketService/ModifyUserSeat"
+	TicketService_PurchaseTicket_FullMethodName         = "/model.TicketService/PurchaseTicket"
+	TicketService_GetReceipt_FullMethodName             = "/model.TicketService/GetReceipt"
+	TicketService_ViewUsersBySection_FullMethodName     = "/model.TicketService/ViewUsersBySection"
+	TicketService_RemoveUser_FullMethodName             = "/model.TicketService/RemoveUser"
+	TicketService_ModifyUserSeat_FullMethodName         = "/model.TicketService/ModifyUserSeat"
+	TicketService_ListMyTickets_FullMethodName          = "/model.TicketService/ListMyTickets"
+	TicketService_WatchAvailability_FullMethodName      = "/model.TicketService/WatchAvailability"
+	TicketService_GetSeatMap_FullMethodName             = "/model.TicketService/GetSeatMap"
+	TicketService_ExportManifest_FullMethodName         = "/model.TicketService/ExportManifest"
+	TicketService_ChangeClass_FullMethodName            = "/model.TicketService/ChangeClass"
+	TicketService_ExchangeTicket_FullMethodName         = "/model.TicketService/ExchangeTicket"
+	TicketService_BlockSeats_FullMethodName             = "/model.TicketService/BlockSeats"
+	TicketService_UnblockSeats_FullMethodName           = "/model.TicketService/UnblockSeats"
+	TicketService_ListSeatBlocks_FullMethodName         = "/model.TicketService/ListSeatBlocks"
+	TicketService_RelocateSection_FullMethodName        = "/model.TicketService/RelocateSection"
+	TicketService_SetOverbooking_FullMethodName         = "/model.TicketService/SetOverbooking"
+	TicketService_GetOverbooking_FullMethodName         = "/model.TicketService/GetOverbooking"
+	TicketService_OffloadSection_FullMethodName         = "/model.TicketService/OffloadSection"
+	TicketService_CheckIn_FullMethodName                = "/model.TicketService/CheckIn"
+	TicketService_Board_FullMethodName                  = "/model.TicketService/Board"
+	TicketService_ProcessNoShows_FullMethodName         = "/model.TicketService/ProcessNoShows"
+	TicketService_GetBoardingPass_FullMethodName        = "/model.TicketService/GetBoardingPass"
+	TicketService_VerifyBoardingPass_FullMethodName     = "/model.TicketService/VerifyBoardingPass"
+	TicketService_GetBoardingPassKey_FullMethodName     = "/model.TicketService/GetBoardingPassKey"
+	TicketService_ExportValidationBundle_FullMethodName = "/model.TicketService/ExportValidationBundle"
+	TicketService_SyncScans_FullMethodName              = "/model.TicketService/SyncScans"
+	TicketService_ConsentToSwap_FullMethodName          = "/model.TicketService/ConsentToSwap"
+	TicketService_SwapSeats_FullMethodName              = "/model.TicketService/SwapSeats"
+	TicketService_CreateTrip_FullMethodName             = "/model.TicketService/CreateTrip"
 )
 
 // TicketServiceClient is the client API for TicketService service.
@@ -37,6 +61,30 @@ type TicketServiceClient interface {
 	ViewUsersBySection(ctx context.Context, in *ViewUsersBySectionRequest, opts ...grpc.CallOption) (*ViewUsersBySectionResponse, error)
 	RemoveUser(ctx context.Context, in *RemoveUserRequest, opts ...grpc.CallOption) (*RemoveUserResponse, error)
 	ModifyUserSeat(ctx context.Context, in *ModifySeatRequest, opts ...grpc.CallOption) (*ModifySeatResponse, error)
+	ListMyTickets(ctx context.Context, in *ListMyTicketsRequest, opts ...grpc.CallOption) (*ListMyTicketsResponse, error)
+	WatchAvailability(ctx context.Context, in *WatchAvailabilityRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AvailabilityUpdate], error)
+	GetSeatMap(ctx context.Context, in *GetSeatMapRequest, opts ...grpc.CallOption) (*GetSeatMapResponse, error)
+	ExportManifest(ctx context.Context, in *ExportManifestRequest, opts ...grpc.CallOption) (*ExportManifestResponse, error)
+	ChangeClass(ctx context.Context, in *ChangeClassRequest, opts ...grpc.CallOption) (*ChangeClassResponse, error)
+	ExchangeTicket(ctx context.Context, in *ExchangeTicketRequest, opts ...grpc.CallOption) (*ExchangeTicketResponse, error)
+	BlockSeats(ctx context.Context, in *BlockSeatsRequest, opts ...grpc.CallOption) (*BlockSeatsResponse, error)
+	UnblockSeats(ctx context.Context, in *UnblockSeatsRequest, opts ...grpc.CallOption) (*UnblockSeatsResponse, error)
+	ListSeatBlocks(ctx context.Context, in *ListSeatBlocksRequest, opts ...grpc.CallOption) (*ListSeatBlocksResponse, error)
+	RelocateSection(ctx context.Context, in *RelocateSectionRequest, opts ...grpc.CallOption) (*RelocateSectionResponse, error)
+	SetOverbooking(ctx context.Context, in *SetOverbookingRequest, opts ...grpc.CallOption) (*SetOverbookingResponse, error)
+	GetOverbooking(ctx context.Context, in *GetOverbookingRequest, opts ...grpc.CallOption) (*GetOverbookingResponse, error)
+	OffloadSection(ctx context.Context, in *OffloadSectionRequest, opts ...grpc.CallOption) (*OffloadSectionResponse, error)
+	CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*CheckInResponse, error)
+	Board(ctx context.Context, in *BoardRequest, opts ...grpc.CallOption) (*BoardResponse, error)
+	ProcessNoShows(ctx context.Context, in *ProcessNoShowsRequest, opts ...grpc.CallOption) (*ProcessNoShowsResponse, error)
+	GetBoardingPass(ctx context.Context, in *GetBoardingPassRequest, opts ...grpc.CallOption) (*GetBoardingPassResponse, error)
+	VerifyBoardingPass(ctx context.Context, in *VerifyBoardingPassRequest, opts ...grpc.CallOption) (*VerifyBoardingPassResponse, error)
+	GetBoardingPassKey(ctx context.Context, in *GetBoardingPassKeyRequest, opts ...grpc.CallOption) (*GetBoardingPassKeyResponse, error)
+	ExportValidationBundle(ctx context.Context, in *ExportValidationBundleRequest, opts ...grpc.CallOption) (*ExportValidationBundleResponse, error)
+	SyncScans(ctx context.Context, in *SyncScansRequest, opts ...grpc.CallOption) (*SyncScansResponse, error)
+	ConsentToSwap(ctx context.Context, in *ConsentToSwapRequest, opts ...grpc.CallOption) (*ConsentToSwapResponse, error)
+	SwapSeats(ctx context.Context, in *SwapSeatsRequest, opts ...grpc.CallOption) (*SwapSeatsResponse, error)
+	CreateTrip(ctx context.Context, in *CreateTripRequest, opts ...grpc.CallOption) (*CreateTripResponse, error)
 }
 
 type ticketServiceClient struct {
@@ -97,6 +145,255 @@ func (c *ticketServiceClient) ModifyUserSeat(ctx context.Context, in *ModifySeat
 	return out, nil
 }
 
+func (c *ticketServiceClient) ListMyTickets(ctx context.Context, in *ListMyTicketsRequest, opts ...grpc.CallOption) (*ListMyTicketsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMyTicketsResponse)
+	err := c.cc.Invoke(ctx, TicketService_ListMyTickets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) WatchAvailability(ctx context.Context, in *WatchAvailabilityRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AvailabilityUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TicketService_ServiceDesc.Streams[0], TicketService_WatchAvailability_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchAvailabilityRequest, AvailabilityUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TicketService_WatchAvailabilityClient = grpc.ServerStreamingClient[AvailabilityUpdate]
+
+func (c *ticketServiceClient) GetSeatMap(ctx context.Context, in *GetSeatMapRequest, opts ...grpc.CallOption) (*GetSeatMapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSeatMapResponse)
+	err := c.cc.Invoke(ctx, TicketService_GetSeatMap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ExportManifest(ctx context.Context, in *ExportManifestRequest, opts ...grpc.CallOption) (*ExportManifestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportManifestResponse)
+	err := c.cc.Invoke(ctx, TicketService_ExportManifest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ChangeClass(ctx context.Context, in *ChangeClassRequest, opts ...grpc.CallOption) (*ChangeClassResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChangeClassResponse)
+	err := c.cc.Invoke(ctx, TicketService_ChangeClass_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ExchangeTicket(ctx context.Context, in *ExchangeTicketRequest, opts ...grpc.CallOption) (*ExchangeTicketResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExchangeTicketResponse)
+	err := c.cc.Invoke(ctx, TicketService_ExchangeTicket_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) BlockSeats(ctx context.Context, in *BlockSeatsRequest, opts ...grpc.CallOption) (*BlockSeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BlockSeatsResponse)
+	err := c.cc.Invoke(ctx, TicketService_BlockSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) UnblockSeats(ctx context.Context, in *UnblockSeatsRequest, opts ...grpc.CallOption) (*UnblockSeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnblockSeatsResponse)
+	err := c.cc.Invoke(ctx, TicketService_UnblockSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ListSeatBlocks(ctx context.Context, in *ListSeatBlocksRequest, opts ...grpc.CallOption) (*ListSeatBlocksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSeatBlocksResponse)
+	err := c.cc.Invoke(ctx, TicketService_ListSeatBlocks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) RelocateSection(ctx context.Context, in *RelocateSectionRequest, opts ...grpc.CallOption) (*RelocateSectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RelocateSectionResponse)
+	err := c.cc.Invoke(ctx, TicketService_RelocateSection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) SetOverbooking(ctx context.Context, in *SetOverbookingRequest, opts ...grpc.CallOption) (*SetOverbookingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetOverbookingResponse)
+	err := c.cc.Invoke(ctx, TicketService_SetOverbooking_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) GetOverbooking(ctx context.Context, in *GetOverbookingRequest, opts ...grpc.CallOption) (*GetOverbookingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOverbookingResponse)
+	err := c.cc.Invoke(ctx, TicketService_GetOverbooking_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) OffloadSection(ctx context.Context, in *OffloadSectionRequest, opts ...grpc.CallOption) (*OffloadSectionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OffloadSectionResponse)
+	err := c.cc.Invoke(ctx, TicketService_OffloadSection_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) CheckIn(ctx context.Context, in *CheckInRequest, opts ...grpc.CallOption) (*CheckInResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckInResponse)
+	err := c.cc.Invoke(ctx, TicketService_CheckIn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) Board(ctx context.Context, in *BoardRequest, opts ...grpc.CallOption) (*BoardResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BoardResponse)
+	err := c.cc.Invoke(ctx, TicketService_Board_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ProcessNoShows(ctx context.Context, in *ProcessNoShowsRequest, opts ...grpc.CallOption) (*ProcessNoShowsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessNoShowsResponse)
+	err := c.cc.Invoke(ctx, TicketService_ProcessNoShows_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) GetBoardingPass(ctx context.Context, in *GetBoardingPassRequest, opts ...grpc.CallOption) (*GetBoardingPassResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBoardingPassResponse)
+	err := c.cc.Invoke(ctx, TicketService_GetBoardingPass_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) VerifyBoardingPass(ctx context.Context, in *VerifyBoardingPassRequest, opts ...grpc.CallOption) (*VerifyBoardingPassResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyBoardingPassResponse)
+	err := c.cc.Invoke(ctx, TicketService_VerifyBoardingPass_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) GetBoardingPassKey(ctx context.Context, in *GetBoardingPassKeyRequest, opts ...grpc.CallOption) (*GetBoardingPassKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBoardingPassKeyResponse)
+	err := c.cc.Invoke(ctx, TicketService_GetBoardingPassKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ExportValidationBundle(ctx context.Context, in *ExportValidationBundleRequest, opts ...grpc.CallOption) (*ExportValidationBundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportValidationBundleResponse)
+	err := c.cc.Invoke(ctx, TicketService_ExportValidationBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) SyncScans(ctx context.Context, in *SyncScansRequest, opts ...grpc.CallOption) (*SyncScansResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncScansResponse)
+	err := c.cc.Invoke(ctx, TicketService_SyncScans_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) ConsentToSwap(ctx context.Context, in *ConsentToSwapRequest, opts ...grpc.CallOption) (*ConsentToSwapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConsentToSwapResponse)
+	err := c.cc.Invoke(ctx, TicketService_ConsentToSwap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) SwapSeats(ctx context.Context, in *SwapSeatsRequest, opts ...grpc.CallOption) (*SwapSeatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SwapSeatsResponse)
+	err := c.cc.Invoke(ctx, TicketService_SwapSeats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketServiceClient) CreateTrip(ctx context.Context, in *CreateTripRequest, opts ...grpc.CallOption) (*CreateTripResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateTripResponse)
+	err := c.cc.Invoke(ctx, TicketService_CreateTrip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TicketServiceServer is the server API for TicketService service.
 // All implementations must embed UnimplementedTicketServiceServer
 // for forward compatibility.
@@ -108,6 +405,30 @@ type TicketServiceServer interface {
 	ViewUsersBySection(context.Context, *ViewUsersBySectionRequest) (*ViewUsersBySectionResponse, error)
 	RemoveUser(context.Context, *RemoveUserRequest) (*RemoveUserResponse, error)
 	ModifyUserSeat(context.Context, *ModifySeatRequest) (*ModifySeatResponse, error)
+	ListMyTickets(context.Context, *ListMyTicketsRequest) (*ListMyTicketsResponse, error)
+	WatchAvailability(*WatchAvailabilityRequest, grpc.ServerStreamingServer[AvailabilityUpdate]) error
+	GetSeatMap(context.Context, *GetSeatMapRequest) (*GetSeatMapResponse, error)
+	ExportManifest(context.Context, *ExportManifestRequest) (*ExportManifestResponse, error)
+	ChangeClass(context.Context, *ChangeClassRequest) (*ChangeClassResponse, error)
+	ExchangeTicket(context.Context, *ExchangeTicketRequest) (*ExchangeTicketResponse, error)
+	BlockSeats(context.Context, *BlockSeatsRequest) (*BlockSeatsResponse, error)
+	UnblockSeats(context.Context, *UnblockSeatsRequest) (*UnblockSeatsResponse, error)
+	ListSeatBlocks(context.Context, *ListSeatBlocksRequest) (*ListSeatBlocksResponse, error)
+	RelocateSection(context.Context, *RelocateSectionRequest) (*RelocateSectionResponse, error)
+	SetOverbooking(context.Context, *SetOverbookingRequest) (*SetOverbookingResponse, error)
+	GetOverbooking(context.Context, *GetOverbookingRequest) (*GetOverbookingResponse, error)
+	OffloadSection(context.Context, *OffloadSectionRequest) (*OffloadSectionResponse, error)
+	CheckIn(context.Context, *CheckInRequest) (*CheckInResponse, error)
+	Board(context.Context, *BoardRequest) (*BoardResponse, error)
+	ProcessNoShows(context.Context, *ProcessNoShowsRequest) (*ProcessNoShowsResponse, error)
+	GetBoardingPass(context.Context, *GetBoardingPassRequest) (*GetBoardingPassResponse, error)
+	VerifyBoardingPass(context.Context, *VerifyBoardingPassRequest) (*VerifyBoardingPassResponse, error)
+	GetBoardingPassKey(context.Context, *GetBoardingPassKeyRequest) (*GetBoardingPassKeyResponse, error)
+	ExportValidationBundle(context.Context, *ExportValidationBundleRequest) (*ExportValidationBundleResponse, error)
+	SyncScans(context.Context, *SyncScansRequest) (*SyncScansResponse, error)
+	ConsentToSwap(context.Context, *ConsentToSwapRequest) (*ConsentToSwapResponse, error)
+	SwapSeats(context.Context, *SwapSeatsRequest) (*SwapSeatsResponse, error)
+	CreateTrip(context.Context, *CreateTripRequest) (*CreateTripResponse, error)
 	mustEmbedUnimplementedTicketServiceServer()
 }
 
@@ -133,6 +454,78 @@ func (UnimplementedTicketServiceServer) RemoveUser(context.Context, *RemoveUserR
 func (UnimplementedTicketServiceServer) ModifyUserSeat(context.Context, *ModifySeatRequest) (*ModifySeatResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ModifyUserSeat not implemented")
 }
+func (UnimplementedTicketServiceServer) ListMyTickets(context.Context, *ListMyTicketsRequest) (*ListMyTicketsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMyTickets not implemented")
+}
+func (UnimplementedTicketServiceServer) WatchAvailability(*WatchAvailabilityRequest, grpc.ServerStreamingServer[AvailabilityUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAvailability not implemented")
+}
+func (UnimplementedTicketServiceServer) GetSeatMap(context.Context, *GetSeatMapRequest) (*GetSeatMapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSeatMap not implemented")
+}
+func (UnimplementedTicketServiceServer) ExportManifest(context.Context, *ExportManifestRequest) (*ExportManifestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportManifest not implemented")
+}
+func (UnimplementedTicketServiceServer) ChangeClass(context.Context, *ChangeClassRequest) (*ChangeClassResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangeClass not implemented")
+}
+func (UnimplementedTicketServiceServer) ExchangeTicket(context.Context, *ExchangeTicketRequest) (*ExchangeTicketResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExchangeTicket not implemented")
+}
+func (UnimplementedTicketServiceServer) BlockSeats(context.Context, *BlockSeatsRequest) (*BlockSeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockSeats not implemented")
+}
+func (UnimplementedTicketServiceServer) UnblockSeats(context.Context, *UnblockSeatsRequest) (*UnblockSeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockSeats not implemented")
+}
+func (UnimplementedTicketServiceServer) ListSeatBlocks(context.Context, *ListSeatBlocksRequest) (*ListSeatBlocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSeatBlocks not implemented")
+}
+func (UnimplementedTicketServiceServer) RelocateSection(context.Context, *RelocateSectionRequest) (*RelocateSectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RelocateSection not implemented")
+}
+func (UnimplementedTicketServiceServer) SetOverbooking(context.Context, *SetOverbookingRequest) (*SetOverbookingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetOverbooking not implemented")
+}
+func (UnimplementedTicketServiceServer) GetOverbooking(context.Context, *GetOverbookingRequest) (*GetOverbookingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOverbooking not implemented")
+}
+func (UnimplementedTicketServiceServer) OffloadSection(context.Context, *OffloadSectionRequest) (*OffloadSectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OffloadSection not implemented")
+}
+func (UnimplementedTicketServiceServer) CheckIn(context.Context, *CheckInRequest) (*CheckInResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckIn not implemented")
+}
+func (UnimplementedTicketServiceServer) Board(context.Context, *BoardRequest) (*BoardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Board not implemented")
+}
+func (UnimplementedTicketServiceServer) ProcessNoShows(context.Context, *ProcessNoShowsRequest) (*ProcessNoShowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessNoShows not implemented")
+}
+func (UnimplementedTicketServiceServer) GetBoardingPass(context.Context, *GetBoardingPassRequest) (*GetBoardingPassResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBoardingPass not implemented")
+}
+func (UnimplementedTicketServiceServer) VerifyBoardingPass(context.Context, *VerifyBoardingPassRequest) (*VerifyBoardingPassResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyBoardingPass not implemented")
+}
+func (UnimplementedTicketServiceServer) GetBoardingPassKey(context.Context, *GetBoardingPassKeyRequest) (*GetBoardingPassKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBoardingPassKey not implemented")
+}
+func (UnimplementedTicketServiceServer) ExportValidationBundle(context.Context, *ExportValidationBundleRequest) (*ExportValidationBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportValidationBundle not implemented")
+}
+func (UnimplementedTicketServiceServer) SyncScans(context.Context, *SyncScansRequest) (*SyncScansResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SyncScans not implemented")
+}
+func (UnimplementedTicketServiceServer) ConsentToSwap(context.Context, *ConsentToSwapRequest) (*ConsentToSwapResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConsentToSwap not implemented")
+}
+func (UnimplementedTicketServiceServer) SwapSeats(context.Context, *SwapSeatsRequest) (*SwapSeatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SwapSeats not implemented")
+}
+func (UnimplementedTicketServiceServer) CreateTrip(context.Context, *CreateTripRequest) (*CreateTripResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTrip not implemented")
+}
 func (UnimplementedTicketServiceServer) mustEmbedUnimplementedTicketServiceServer() {}
 func (UnimplementedTicketServiceServer) testEmbeddedByValue()                       {}
 
@@ -244,32 +637,889 @@ func _TicketService_ModifyUserSeat_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
-// TicketService_ServiceDesc is the grpc.ServiceDesc for TicketService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var TicketService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "model.TicketService",
-	HandlerType: (*TicketServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "PurchaseTicket",
-			Handler:    _TicketService_PurchaseTicket_Handler,
-		},
-		{
-			MethodName: "GetReceipt",
-			Handler:    _TicketService_GetReceipt_Handler,
-		},
-		{
-			MethodName: "ViewUsersBySection",
-			Handler:    _TicketService_ViewUsersBySection_Handler,
-		},
-		{
-			MethodName: "RemoveUser",
-			Handler:    _TicketService_RemoveUser_Handler,
-		},
-		{
-			MethodName: "ModifyUserSeat",
-			Handler:    _TicketService_ModifyUserSeat_Handler,
+func _TicketService_ListMyTickets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMyTicketsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ListMyTickets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ListMyTickets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ListMyTickets(ctx, req.(*ListMyTicketsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_WatchAvailability_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAvailabilityRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TicketServiceServer).WatchAvailability(m, &grpc.GenericServerStream[WatchAvailabilityRequest, AvailabilityUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TicketService_WatchAvailabilityServer = grpc.ServerStreamingServer[AvailabilityUpdate]
+
+func _TicketService_GetSeatMap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSeatMapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).GetSeatMap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_GetSeatMap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).GetSeatMap(ctx, req.(*GetSeatMapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ExportManifest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportManifestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ExportManifest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ExportManifest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ExportManifest(ctx, req.(*ExportManifestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ChangeClass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangeClassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ChangeClass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ChangeClass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ChangeClass(ctx, req.(*ChangeClassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ExchangeTicket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeTicketRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ExchangeTicket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ExchangeTicket_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ExchangeTicket(ctx, req.(*ExchangeTicketRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_BlockSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).BlockSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_BlockSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).BlockSeats(ctx, req.(*BlockSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_UnblockSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnblockSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).UnblockSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_UnblockSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).UnblockSeats(ctx, req.(*UnblockSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ListSeatBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSeatBlocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ListSeatBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ListSeatBlocks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ListSeatBlocks(ctx, req.(*ListSeatBlocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_RelocateSection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelocateSectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).RelocateSection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_RelocateSection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).RelocateSection(ctx, req.(*RelocateSectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_SetOverbooking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetOverbookingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).SetOverbooking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_SetOverbooking_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).SetOverbooking(ctx, req.(*SetOverbookingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_GetOverbooking_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOverbookingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).GetOverbooking(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_GetOverbooking_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).GetOverbooking(ctx, req.(*GetOverbookingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_OffloadSection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OffloadSectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).OffloadSection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_OffloadSection_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).OffloadSection(ctx, req.(*OffloadSectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_CheckIn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckInRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).CheckIn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_CheckIn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).CheckIn(ctx, req.(*CheckInRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_Board_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BoardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).Board(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_Board_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).Board(ctx, req.(*BoardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ProcessNoShows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessNoShowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ProcessNoShows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ProcessNoShows_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ProcessNoShows(ctx, req.(*ProcessNoShowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_GetBoardingPass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBoardingPassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).GetBoardingPass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_GetBoardingPass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).GetBoardingPass(ctx, req.(*GetBoardingPassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_VerifyBoardingPass_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyBoardingPassRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).VerifyBoardingPass(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_VerifyBoardingPass_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).VerifyBoardingPass(ctx, req.(*VerifyBoardingPassRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_GetBoardingPassKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBoardingPassKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).GetBoardingPassKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_GetBoardingPassKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).GetBoardingPassKey(ctx, req.(*GetBoardingPassKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ExportValidationBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportValidationBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ExportValidationBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ExportValidationBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ExportValidationBundle(ctx, req.(*ExportValidationBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_SyncScans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncScansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).SyncScans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_SyncScans_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).SyncScans(ctx, req.(*SyncScansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_ConsentToSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsentToSwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).ConsentToSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_ConsentToSwap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).ConsentToSwap(ctx, req.(*ConsentToSwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_SwapSeats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwapSeatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).SwapSeats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_SwapSeats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).SwapSeats(ctx, req.(*SwapSeatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketService_CreateTrip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTripRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketServiceServer).CreateTrip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketService_CreateTrip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketServiceServer).CreateTrip(ctx, req.(*CreateTripRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TicketService_ServiceDesc is the grpc.ServiceDesc for TicketService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TicketService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "model.TicketService",
+	HandlerType: (*TicketServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PurchaseTicket",
+			Handler:    _TicketService_PurchaseTicket_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _TicketService_GetReceipt_Handler,
+		},
+		{
+			MethodName: "ViewUsersBySection",
+			Handler:    _TicketService_ViewUsersBySection_Handler,
+		},
+		{
+			MethodName: "RemoveUser",
+			Handler:    _TicketService_RemoveUser_Handler,
+		},
+		{
+			MethodName: "ModifyUserSeat",
+			Handler:    _TicketService_ModifyUserSeat_Handler,
+		},
+		{
+			MethodName: "ListMyTickets",
+			Handler:    _TicketService_ListMyTickets_Handler,
+		},
+		{
+			MethodName: "GetSeatMap",
+			Handler:    _TicketService_GetSeatMap_Handler,
+		},
+		{
+			MethodName: "ExportManifest",
+			Handler:    _TicketService_ExportManifest_Handler,
+		},
+		{
+			MethodName: "ChangeClass",
+			Handler:    _TicketService_ChangeClass_Handler,
+		},
+		{
+			MethodName: "ExchangeTicket",
+			Handler:    _TicketService_ExchangeTicket_Handler,
+		},
+		{
+			MethodName: "BlockSeats",
+			Handler:    _TicketService_BlockSeats_Handler,
+		},
+		{
+			MethodName: "UnblockSeats",
+			Handler:    _TicketService_UnblockSeats_Handler,
+		},
+		{
+			MethodName: "ListSeatBlocks",
+			Handler:    _TicketService_ListSeatBlocks_Handler,
+		},
+		{
+			MethodName: "RelocateSection",
+			Handler:    _TicketService_RelocateSection_Handler,
+		},
+		{
+			MethodName: "SetOverbooking",
+			Handler:    _TicketService_SetOverbooking_Handler,
+		},
+		{
+			MethodName: "GetOverbooking",
+			Handler:    _TicketService_GetOverbooking_Handler,
+		},
+		{
+			MethodName: "OffloadSection",
+			Handler:    _TicketService_OffloadSection_Handler,
+		},
+		{
+			MethodName: "CheckIn",
+			Handler:    _TicketService_CheckIn_Handler,
+		},
+		{
+			MethodName: "Board",
+			Handler:    _TicketService_Board_Handler,
+		},
+		{
+			MethodName: "ProcessNoShows",
+			Handler:    _TicketService_ProcessNoShows_Handler,
+		},
+		{
+			MethodName: "GetBoardingPass",
+			Handler:    _TicketService_GetBoardingPass_Handler,
+		},
+		{
+			MethodName: "VerifyBoardingPass",
+			Handler:    _TicketService_VerifyBoardingPass_Handler,
+		},
+		{
+			MethodName: "GetBoardingPassKey",
+			Handler:    _TicketService_GetBoardingPassKey_Handler,
+		},
+		{
+			MethodName: "ExportValidationBundle",
+			Handler:    _TicketService_ExportValidationBundle_Handler,
+		},
+		{
+			MethodName: "SyncScans",
+			Handler:    _TicketService_SyncScans_Handler,
+		},
+		{
+			MethodName: "ConsentToSwap",
+			Handler:    _TicketService_ConsentToSwap_Handler,
+		},
+		{
+			MethodName: "SwapSeats",
+			Handler:    _TicketService_SwapSeats_Handler,
+		},
+		{
+			MethodName: "CreateTrip",
+			Handler:    _TicketService_CreateTrip_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchAvailability",
+			Handler:       _TicketService_WatchAvailability_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ticket.proto",
+}
+
+const (
+	UserService_RegisterUser_FullMethodName   = "/model.UserService/RegisterUser"
+	UserService_GetUser_FullMethodName        = "/model.UserService/GetUser"
+	UserService_UpdateUser_FullMethodName     = "/model.UserService/UpdateUser"
+	UserService_DeleteUser_FullMethodName     = "/model.UserService/DeleteUser"
+	UserService_ExportUserData_FullMethodName = "/model.UserService/ExportUserData"
+	UserService_EraseUser_FullMethodName      = "/model.UserService/EraseUser"
+	UserService_IssueUserToken_FullMethodName = "/model.UserService/IssueUserToken"
+)
+
+// UserServiceClient is the client API for UserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// User Service Definition
+type UserServiceClient interface {
+	RegisterUser(ctx context.Context, in *RegisterUserRequest, opts ...grpc.CallOption) (*RegisterUserResponse, error)
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error)
+	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UpdateUserResponse, error)
+	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error)
+	EraseUser(ctx context.Context, in *EraseUserRequest, opts ...grpc.CallOption) (*EraseUserResponse, error)
+	IssueUserToken(ctx context.Context, in *IssueUserTokenRequest, opts ...grpc.CallOption) (*IssueUserTokenResponse, error)
+}
+
+type userServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUserServiceClient(cc grpc.ClientConnInterface) UserServiceClient {
+	return &userServiceClient{cc}
+}
+
+func (c *userServiceClient) RegisterUser(ctx context.Context, in *RegisterUserRequest, opts ...grpc.CallOption) (*RegisterUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterUserResponse)
+	err := c.cc.Invoke(ctx, UserService_RegisterUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserResponse)
+	err := c.cc.Invoke(ctx, UserService_GetUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UpdateUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateUserResponse)
+	err := c.cc.Invoke(ctx, UserService_UpdateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteUserResponse)
+	err := c.cc.Invoke(ctx, UserService_DeleteUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportUserDataResponse)
+	err := c.cc.Invoke(ctx, UserService_ExportUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) EraseUser(ctx context.Context, in *EraseUserRequest, opts ...grpc.CallOption) (*EraseUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EraseUserResponse)
+	err := c.cc.Invoke(ctx, UserService_EraseUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) IssueUserToken(ctx context.Context, in *IssueUserTokenRequest, opts ...grpc.CallOption) (*IssueUserTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IssueUserTokenResponse)
+	err := c.cc.Invoke(ctx, UserService_IssueUserToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UserServiceServer is the server API for UserService service.
+// All implementations must embed UnimplementedUserServiceServer
+// for forward compatibility.
+//
+// User Service Definition
+type UserServiceServer interface {
+	RegisterUser(context.Context, *RegisterUserRequest) (*RegisterUserResponse, error)
+	GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error)
+	UpdateUser(context.Context, *UpdateUserRequest) (*UpdateUserResponse, error)
+	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
+	ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error)
+	EraseUser(context.Context, *EraseUserRequest) (*EraseUserResponse, error)
+	IssueUserToken(context.Context, *IssueUserTokenRequest) (*IssueUserTokenResponse, error)
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+// UnimplementedUserServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedUserServiceServer struct{}
+
+func (UnimplementedUserServiceServer) RegisterUser(context.Context, *RegisterUserRequest) (*RegisterUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterUser not implemented")
+}
+func (UnimplementedUserServiceServer) GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedUserServiceServer) UpdateUser(context.Context, *UpdateUserRequest) (*UpdateUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedUserServiceServer) DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedUserServiceServer) ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedUserServiceServer) EraseUser(context.Context, *EraseUserRequest) (*EraseUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUser not implemented")
+}
+func (UnimplementedUserServiceServer) IssueUserToken(context.Context, *IssueUserTokenRequest) (*IssueUserTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueUserToken not implemented")
+}
+func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
+func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UserServiceServer will
+// result in compilation errors.
+type UnsafeUserServiceServer interface {
+	mustEmbedUnimplementedUserServiceServer()
+}
+
+func RegisterUserServiceServer(s grpc.ServiceRegistrar, srv UserServiceServer) {
+	// If the following call pancis, it indicates UnimplementedUserServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&UserService_ServiceDesc, srv)
+}
+
+func _UserService_RegisterUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RegisterUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RegisterUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RegisterUser(ctx, req.(*RegisterUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UpdateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdateUser(ctx, req.(*UpdateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_DeleteUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).DeleteUser(ctx, req.(*DeleteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ExportUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ExportUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ExportUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ExportUserData(ctx, req.(*ExportUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_EraseUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).EraseUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_EraseUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).EraseUser(ctx, req.(*EraseUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_IssueUserToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueUserTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).IssueUserToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_IssueUserToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).IssueUserToken(ctx, req.(*IssueUserTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "model.UserService",
+	HandlerType: (*UserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterUser",
+			Handler:    _UserService_RegisterUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _UserService_GetUser_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _UserService_UpdateUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _UserService_DeleteUser_Handler,
+		},
+		{
+			MethodName: "ExportUserData",
+			Handler:    _UserService_ExportUserData_Handler,
+		},
+		{
+			MethodName: "EraseUser",
+			Handler:    _UserService_EraseUser_Handler,
+		},
+		{
+			MethodName: "IssueUserToken",
+			Handler:    _UserService_IssueUserToken_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},